@@ -0,0 +1,10 @@
+name="Simple Sam"
+timeout="30s"
+started="2022-01-02T15:04:05Z"
+birthday="2022-01-02"
+location="Europe/Berlin"
+
+server {
+    read_timeout="1m30s"
+    idle="5s"
+}
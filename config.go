@@ -1,46 +1,71 @@
 package config
 
 import (
-	"encoding/json"
+	"encoding"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
-	"os"
-	"path"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
-
-	"github.com/alecthomas/hcl"
-	"github.com/pelletier/go-toml"
-	"gopkg.in/yaml.v3"
 )
 
+// The package level variables configure the Loader used by the package level functions.
 var (
-	EnvDelimeter      = "_"
+	// EnvDelimeter separates the parts of an env variable name.
+	//
+	// Deprecated: use the WithEnvDelimiter option of NewLoader.
+	EnvDelimeter = "_"
+	// EnvSliceDelimeter separates the elements of slices and map entries.
+	//
+	// Deprecated: use the WithEnvSliceDelimiter option of NewLoader.
 	EnvSliceDelimeter = ";"
+	// EnvMapKeyValueDelimeter separates the key and the value of a map entry, e.g. CFG_LABELS="team=core;tier=1".
+	// The entries themselves are separated by EnvSliceDelimeter.
+	//
+	// Deprecated: use the WithEnvMapKeyValueDelimiter option of NewLoader.
+	EnvMapKeyValueDelimeter = "="
+	// EnvIgnoreParseErrors enables the lenient mode in which env variables that can not be parsed are skipped
+	// instead of returning an error.
+	//
+	// Deprecated: use the WithStrict option of NewLoader.
+	EnvIgnoreParseErrors = false
 )
 
-type format string
-
 const (
-	YAML format = "yaml"
-	JSON format = "json"
-	TOML format = "toml"
-	HCL  format = "hcl"
+	// envTag is the struct tag used to override the name of the environment variable of a field.
+	// The tag value is the name of the variable followed by optional comma separated options.
+	// A value of "-" excludes the field from the env enrichment.
+	//
+	// Supported options:
+	// - noprefix: the name is used as is without the prefix of the parent.
+	envTag = "env"
+	// envTagOptionNoPrefix disables the prefix for the env variable of a field.
+	envTagOptionNoPrefix = "noprefix"
+)
+
+// EnvDecoder can be implemented by custom types to decode themselves from the value of an env variable.
+// It takes precedence over encoding.TextUnmarshaler and the kind based parsing.
+type EnvDecoder interface {
+	DecodeEnv(value string) error
+}
+
+var (
+	envDecoderType      = reflect.TypeOf((*EnvDecoder)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	urlType             = reflect.TypeOf(url.URL{})
 )
 
 // AutoloadAndEnrichConfig takes a config file and a receiver and enriches the config with the value from env variables.
 // @filePath: The path to the config file.
 // @receiver: The receiver to parse the config file into.
 // @prefix: The prefix to use for the env variables.
+//
+// Finally the receiver is validated, see Validate.
 func AutoloadAndEnrichConfigWithEnvPrefix(filePath string, prefix string, receiver interface{}) error {
-	fileFormat := detectFormat(filePath)
-	err := loadAndParseFile(filePath, receiver, fileFormat)
-	if err != nil {
-		return err
-	}
-	readStructAndEnrichWithEnv(receiver, prefix)
-	return nil
+	return defaultLoader(WithEnvPrefix(prefix)).Load(filePath, receiver)
 }
 
 // AutoloadAndEnrichConfig takes a config file and a receiver and enriches the config with the value from env variables.
@@ -52,165 +77,740 @@ func AutoloadAndEnrichConfig(filePath string, receiver interface{}) error {
 	return AutoloadAndEnrichConfigWithEnvPrefix(filePath, "CFG", receiver)
 }
 
-// detectFormat detects the format of the config file.
-// @filePath: The path to the config file.
-func detectFormat(filePath string) format {
-	ext := path.Ext(filePath)
-	switch ext {
-	case ".yaml", ".yml":
-		return YAML
-	case ".json":
-		return JSON
-	case ".toml":
-		return TOML
-	case ".hcl":
-		return HCL
-	default:
-		return ""
-	}
+// AutoloadAndEnrichConfigsWithEnvPrefix takes multiple config files and a receiver, merges the files in the given order
+// and enriches the config with the value from env variables.
+// @prefix: The prefix to use for the env variables.
+// @policy: The policy to use when merging slices.
+// @receiver: The receiver to parse the config files into.
+// @filePaths: The paths to the config files. Later files take precedence over earlier ones.
+//
+// Fields that are not set in a later file keep the value of an earlier file, see Loader.LoadFiles.
+// Finally the receiver is validated, see Validate.
+func AutoloadAndEnrichConfigsWithEnvPrefix(prefix string, policy SliceMergePolicy, receiver interface{}, filePaths ...string) error {
+	return defaultLoader(WithEnvPrefix(prefix), WithSliceMergePolicy(policy)).LoadFiles(receiver, filePaths...)
+}
+
+// AutoloadAndEnrichConfigs takes multiple config files and a receiver, merges the files in the given order
+// and enriches the config with the value from env variables.
+// @receiver: The receiver to parse the config files into.
+// @filePaths: The paths to the config files. Later files take precedence over earlier ones.
+//
+// By default the prefix is set to CFG and slices are replaced.
+func AutoloadAndEnrichConfigs(receiver interface{}, filePaths ...string) error {
+	return AutoloadAndEnrichConfigsWithEnvPrefix("CFG", SliceReplace, receiver, filePaths...)
 }
 
 // loadAndParseFile takes a config file and a receiver and parses the config file into the receiver.
 // @filePath: The path to the config file.
 // @receiver: The receiver to parse the config file into.
-// @f: The format of the config file.
-func loadAndParseFile(filePath string, receiver interface{}, f format) error {
+// @f: The format of the config file, see parseBytes.
+func (l *Loader) loadAndParseFile(filePath string, receiver interface{}, f Format) error {
 	bts, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
-	switch f {
-	case YAML:
-		return yaml.Unmarshal(bts, receiver)
-	case JSON:
-		return json.Unmarshal(bts, receiver)
-	case TOML:
-		return toml.Unmarshal(bts, receiver)
-	case HCL:
-		return hcl.Unmarshal(bts, receiver)
-		//return hcl.Unmarshal(bts, receiver)
-		//return hcl.Unmarshal(bts, receiver)
-	default:
-		return fmt.Errorf("unsupported format: %s", f)
+	l.rec.parsing(filePath)
+	return l.parseBytes(bts, receiver, f)
+}
+
+// loadAndParseFS takes a config file of the file system fsys and a receiver and parses the config file into the receiver.
+// @fsys: The file system to read the config file from.
+// @filePath: The slash separated path to the config file within fsys.
+// @receiver: The receiver to parse the config file into.
+// @f: The format of the config file, see parseBytes.
+func (l *Loader) loadAndParseFS(fsys fs.FS, filePath string, receiver interface{}, f Format) error {
+	bts, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return err
+	}
+	l.rec.parsing(filePath)
+	return l.parseBytes(bts, receiver, f)
+}
+
+// parseBytes parses the content of a config file into the receiver.
+// @bts: The content of the config file.
+// @receiver: The receiver to parse the config file into.
+// @f: The format detected by the file extension or passed by the caller. It is resolved by resolveFormat.
+func (l *Loader) parseBytes(bts []byte, receiver interface{}, f Format) error {
+	_, err := l.parseBytesDocument(bts, receiver, f, false)
+	return err
+}
+
+// parseBytesDocument parses the content of a config file into the receiver, see parseBytes.
+// If withDocument is true, the document of the content is returned as well, so it can be checked which keys
+// the config file defines. The document is nil for formats without document, e.g. formats registered by RegisterFormat.
+// @bts: The content of the config file.
+// @receiver: The receiver to parse the config file into.
+// @f: The format detected by the file extension or passed by the caller. It is resolved by resolveFormat.
+// @withDocument: Whether the document of the content is returned.
+func (l *Loader) parseBytesDocument(bts []byte, receiver interface{}, f Format, withDocument bool) (document, error) {
+	if l.interpolation {
+		env, err := l.snapshotEnv()
+		if err != nil {
+			return nil, err
+		}
+		content, err := interpolate(string(bts), func(name string) (string, bool) {
+			value, ok := env.vars[name]
+			return value, ok
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to interpolate env variables: %w", err)
+		}
+		bts = []byte(content)
+	}
+	f, err := l.resolveFormat(f, bts)
+	if err != nil {
+		return nil, err
+	}
+	if !l.supportsFormat(f) {
+		return nil, fmt.Errorf("unsupported format: %s", f)
+	}
+	err = l.decodeWithTimeValues(bts, receiver, f)
+	if err != nil {
+		return nil, err
+	}
+	if !withDocument && l.rec == nil {
+		return nil, nil
 	}
+	// decodeWithTimeValues modifies its document, so the content is parsed again
+	doc, err := parseDocument(bts, f)
+	if err != nil {
+		return nil, err
+	}
+	err = l.recordFile(doc, bts, receiver, f)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
 }
 
 // prefixString returns the string s with prefix p.
-func prefixString(prefix, fieldName string) string {
+func (l *Loader) prefixString(prefix, fieldName string) string {
 	if prefix == "" {
 		return strings.ToUpper(fieldName)
 	}
-	return strings.ToUpper(fmt.Sprintf("%s%s%s", prefix, EnvDelimeter, fieldName))
+	return strings.ToUpper(fmt.Sprintf("%s%s%s", prefix, l.envDelimiter, fieldName))
+}
+
+// envName returns the name of the env variable for the field based on the prefix and the env tag.
+// If the field is excluded from the env enrichment, skip is true.
+// @prefix: The prefix of the parent.
+// @field: The struct field to return the env variable name for.
+func (l *Loader) envName(prefix string, field reflect.StructField) (name string, skip bool) {
+	name, noPrefix, skip := envTagName(field)
+	if skip {
+		return "", true
+	}
+	if noPrefix {
+		prefix = ""
+	}
+	return l.prefixString(prefix, name), false
+}
+
+// envTagName returns the name of the field as defined by the env tag or the name of the field if no name is defined.
+// noPrefix is true if the noprefix option is set, skip is true if the field is excluded by "-".
+// @field: The struct field to return the name for.
+func envTagName(field reflect.StructField) (name string, noPrefix, skip bool) {
+	tag, ok := field.Tag.Lookup(envTag)
+	if !ok {
+		return field.Name, false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range parts[1:] {
+		if strings.TrimSpace(option) == envTagOptionNoPrefix {
+			noPrefix = true
+		}
+	}
+	return name, noPrefix, false
+}
+
+// envEnricher holds the state of a single env enrichment.
+type envEnricher struct {
+	*Loader
+	env  *envSnapshot
+	errs EnvErrors
 }
 
-func readStructAndEnrichWithEnv(st interface{}, prefix string) {
+// readStructAndEnrichWithEnv walks through the struct st and overrides each field with the value of the matching env variable.
+// All values that could not be parsed are collected and returned as EnvErrors, unless the Loader is not strict.
+// @st: The pointer to the struct to enrich.
+// @prefix: The prefix to use for the env variables.
+func (l *Loader) readStructAndEnrichWithEnv(st interface{}, prefix string) error {
 	val := reflect.ValueOf(st)
 	if val.Kind() == reflect.Ptr {
 		val = val.Elem()
 	}
+	env, err := l.snapshotEnv()
+	if err != nil {
+		return err
+	}
+	e := &envEnricher{Loader: l, env: env}
+	e.enrichStructWithEnv(val, prefix, "", false)
+	if len(e.errs) == 0 || !l.strict {
+		return nil
+	}
+	return e.errs
+}
+
+// enrichStructWithEnv is the recursive part of readStructAndEnrichWithEnv.
+// It reports whether at least one env variable has been found for the fields of the struct.
+// @val: The struct value to enrich.
+// @prefix: The prefix to use for the env variables.
+// @fieldPath: The path of val within the receiver, used for error reporting.
+// @secret: Whether val is a secret, see isSecret. The values of secret fields do not show up in errors.
+func (e *envEnricher) enrichStructWithEnv(val reflect.Value, prefix, fieldPath string, secret bool) bool {
+	found := false
 	for i := 0; i < val.NumField(); i++ {
-		// fmt.Println(val.Type().Field(i).Type.Kind())
 		f := val.Field(i)
-		prefixedFieldName := prefixString(prefix, val.Type().Field(i).Name)
-		osEnv := os.Getenv(prefixedFieldName)
-		switch f.Kind() {
-		case reflect.Struct:
-			readStructAndEnrichWithEnv(f.Addr().Interface(), prefixedFieldName)
-		case reflect.Slice:
-			_, ok := f.Interface().([]string)
-			if !ok {
-				// we only support []string from env
-				continue
-			}
-			if osEnv == "" {
-				// env var not set
-				continue
-			}
-			f.Set(
-				reflect.ValueOf(
-					strings.Split(osEnv, EnvSliceDelimeter),
-				),
-			)
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			if osEnv == "" {
-				continue
-			}
-			in, err := strconv.ParseInt(osEnv, 10, 64)
-			if err != nil {
-				// could not parse int
-				// so we skip this field
-				continue
-			}
-			if !f.IsValid() {
-				f.SetInt(in)
-				continue
+		if !f.CanSet() {
+			// unexported field
+			continue
+		}
+		field := val.Type().Field(i)
+		prefixedFieldName, skip := e.envName(prefix, field)
+		if skip {
+			continue
+		}
+		if e.enrichValueWithEnv(f, prefixedFieldName, joinFieldPath(fieldPath, field.Name), secret || isSecret(field)) {
+			found = true
+		}
+	}
+	return found
+}
+
+// enrichValueWithEnv sets the value v from the env variable name or, for structs and maps, from the variables below name.
+// It reports whether at least one env variable has been found.
+// Nil pointers are only allocated if an env variable has been found for them, otherwise they are left nil.
+// @v: The settable value to enrich.
+// @name: The name of the env variable.
+// @fieldPath: The path of v within the receiver, used for error reporting.
+// @secret: Whether v is a secret, see isSecret. The values of secret fields do not show up in errors.
+func (e *envEnricher) enrichValueWithEnv(v reflect.Value, name, fieldPath string, secret bool) bool {
+	switch {
+	case isScalarType(v.Type()):
+		// scalar types are set as a whole
+	case v.Kind() == reflect.Struct:
+		return e.enrichStructWithEnv(v, name, fieldPath, secret)
+	case v.Kind() == reflect.Ptr:
+		if !v.IsNil() {
+			return e.enrichValueWithEnv(v.Elem(), name, fieldPath, secret)
+		}
+		fresh := reflect.New(v.Type().Elem())
+		errCount := len(e.errs)
+		if !e.enrichValueWithEnv(fresh.Elem(), name, fieldPath, secret) {
+			return false
+		}
+		if len(e.errs) > errCount && fresh.Elem().IsZero() {
+			// only invalid env variables have been found
+			return true
+		}
+		v.Set(fresh)
+		return true
+	}
+
+	found := false
+	sourceName, osEnv, err := e.lookupEnv(name)
+	if err != nil {
+		found = true
+		e.errs = append(e.errs, &EnvParseError{
+			Name:  sourceName,
+			Field: fieldPath,
+			Value: osEnv,
+			Err:   err,
+		})
+	} else if osEnv != "" {
+		found = true
+		err := unsupportedTypeError(v.Type())
+		if err == nil {
+			err = e.setValueFromString(v, osEnv)
+		}
+		if err != nil {
+			e.errs = append(e.errs, e.parseError(sourceName, fieldPath, e.env.vars[sourceName], v.Type(), err,
+				secret, sourceName != name))
+		} else {
+			e.rec.record(fieldPath, Source{Kind: SourceEnv, Name: sourceName})
+		}
+	}
+	if v.Kind() == reflect.Map && isElementType(v.Type().Elem()) && e.enrichMapKeysWithEnv(v, name, fieldPath, secret) {
+		found = true
+	}
+	if v.Kind() == reflect.Map && !isElementType(v.Type().Elem()) && e.enrichMapElementsWithEnv(v, name, fieldPath, secret) {
+		found = true
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !isElementType(v.Type().Elem()) &&
+		e.enrichIndexedElementsWithEnv(v, name, fieldPath, secret) {
+		found = true
+	}
+	return found
+}
+
+// parseError returns the error for an env variable whose value could not be parsed.
+// The values of secret fields and the contents of files are hidden, since the underlying errors may contain them.
+// @name: The name of the env variable.
+// @fieldPath: The path of the target field.
+// @value: The raw value of the env variable.
+// @typ: The type the value has been parsed into.
+// @err: The underlying parse error.
+// @secret: Whether the target field is a secret, see isSecret.
+// @file: Whether the value has been read from a file, see lookupEnv.
+func (e *envEnricher) parseError(name, fieldPath, value string, typ reflect.Type, err error, secret, file bool) *EnvParseError {
+	if secret || file {
+		err = &secretValueError{typ: typ, err: err, file: file}
+	}
+	if secret && !file {
+		value = RedactedValue
+	}
+	return &EnvParseError{
+		Name:  name,
+		Field: fieldPath,
+		Value: value,
+		Err:   err,
+	}
+}
+
+// lookupEnv returns the value of the env variable name. If the variable <name>_FILE is set instead,
+// the value is read from the file it points to and a single trailing newline is removed,
+// e.g. CFG_DB_PASSWORD_FILE=/run/secrets/db_password. Setting both variables is an error.
+// sourceName is the name of the variable that provided the value. If reading the file fails,
+// the path of the file is returned as value.
+// @name: The name of the env variable.
+func (e *envEnricher) lookupEnv(name string) (sourceName, value string, err error) {
+	value = e.env.vars[name]
+	fileName := e.fileEnvName(name)
+	filePath := e.env.vars[fileName]
+	if filePath == "" {
+		return name, value, nil
+	}
+	if value != "" {
+		return fileName, filePath, fmt.Errorf("both %s and %s are set", name, fileName)
+	}
+	bts, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return fileName, filePath, err
+	}
+	value = strings.TrimSuffix(string(bts), "\n")
+	return fileName, strings.TrimSuffix(value, "\r"), nil
+}
+
+// fileEnvName returns the name of the env variable that points to a file containing the value of the variable name.
+func (l *Loader) fileEnvName(name string) string {
+	return name + l.envDelimiter + "FILE"
+}
+
+// enrichIndexedElementsWithEnv enriches the elements of the slice or array v from indexed env variables,
+// e.g. CFG_UPSTREAMS_0_HOST. Slices are grown as needed, existing elements are enriched in place.
+// Slices can be grown by at most the number of distinct indexes, larger indexes are reported as EnvParseError.
+// It reports whether at least one env variable has been found.
+// @v: The settable slice or array value to enrich.
+// @prefix: The env variable name of the slice.
+// @fieldPath: The path of v within the receiver, used for error reporting.
+// @secret: Whether v is a secret, see isSecret.
+func (e *envEnricher) enrichIndexedElementsWithEnv(v reflect.Value, prefix, fieldPath string, secret bool) bool {
+	keyPrefix := strings.ToUpper(prefix + e.envDelimiter)
+	indexes := map[int]bool{}
+	for _, env := range e.env.names {
+		if !strings.HasPrefix(env, keyPrefix) {
+			continue
+		}
+		rest := env[len(keyPrefix):]
+		end := strings.Index(rest, e.envDelimiter)
+		if end < 0 {
+			continue
+		}
+		idx, err := strconv.Atoi(rest[:end])
+		if err != nil || idx < 0 {
+			continue
+		}
+		indexes[idx] = true
+	}
+	if len(indexes) == 0 {
+		return false
+	}
+
+	// the length of slices is limited by the number of indexes, so that a single variable can not allocate
+	// an arbitrary amount of memory
+	length := v.Len()
+	outOfRange := fmt.Errorf("index out of range, the array has a length of %d", length)
+	if v.Kind() == reflect.Slice {
+		length += len(indexes)
+		outOfRange = fmt.Errorf("index out of range, the slice can be grown to a length of %d", length)
+	}
+	maxIndex := -1
+	invalid := []int{}
+	for idx := range indexes {
+		switch {
+		case idx >= length:
+			invalid = append(invalid, idx)
+		case idx > maxIndex:
+			maxIndex = idx
+		}
+	}
+	sort.Ints(invalid)
+	for _, idx := range invalid {
+		e.errs = append(e.errs, &EnvParseError{
+			Name:  e.prefixString(prefix, strconv.Itoa(idx)),
+			Field: fmt.Sprintf("%s[%d]", fieldPath, idx),
+			Err:   outOfRange,
+		})
+	}
+
+	elems := v
+	grown := v.Kind() == reflect.Slice && maxIndex >= v.Len()
+	if grown {
+		// indexes without env variables are filled with zero values
+		elems = reflect.MakeSlice(v.Type(), maxIndex+1, maxIndex+1)
+		reflect.Copy(elems, v)
+	}
+	found := false
+	for i := 0; i <= maxIndex; i++ {
+		elemName := e.prefixString(prefix, strconv.Itoa(i))
+		elemPath := fmt.Sprintf("%s[%d]", fieldPath, i)
+		if e.enrichValueWithEnv(elems.Index(i), elemName, elemPath, secret) {
+			found = true
+		}
+	}
+	if grown {
+		v.Set(elems)
+	}
+	return found
+}
+
+// enrichMapKeysWithEnv sets the entries of the map m from env variables that start with the prefix, e.g. CFG_LABELS_TEAM.
+// The elements of m must be parsed from a single string, see isElementType and enrichMapElementsWithEnv.
+// The remainder of the variable name is used as key. If the map already contains the key in a different case,
+// the existing key is used, otherwise the key is lower cased. The map is created if it is nil.
+// It reports whether at least one env variable has been found.
+// @m: The settable map value to enrich.
+// @prefix: The env variable name of the map.
+// @fieldPath: The path of m within the receiver, used for error reporting.
+// @secret: Whether m is a secret, see isSecret.
+func (e *envEnricher) enrichMapKeysWithEnv(m reflect.Value, prefix, fieldPath string, secret bool) bool {
+	found := false
+	keyPrefix := strings.ToUpper(prefix + e.envDelimiter)
+	for _, name := range e.env.names {
+		value := e.env.vars[name]
+		if !strings.HasPrefix(name, keyPrefix) || len(name) == len(keyPrefix) || value == "" || name == e.fileEnvName(prefix) {
+			continue
+		}
+		found = true
+		rawKey := strings.ToLower(name[len(keyPrefix):])
+		if !m.IsNil() {
+			iter := m.MapRange()
+			for iter.Next() {
+				if strings.EqualFold(fmt.Sprint(iter.Key().Interface()), rawKey) {
+					rawKey = fmt.Sprint(iter.Key().Interface())
+					break
+				}
 			}
-			if f.CanSet() {
-				f.SetInt(in)
+		}
+		err := e.setMapEntryFromString(m, rawKey, value)
+		if err != nil {
+			e.errs = append(e.errs, e.parseError(name, fmt.Sprintf("%s[%s]", fieldPath, rawKey), value, m.Type().Elem(), err,
+				secret, false))
+			continue
+		}
+		e.rec.record(fmt.Sprintf("%s[%s]", fieldPath, rawKey), Source{Kind: SourceEnv, Name: name})
+	}
+	return found
+}
+
+// enrichMapElementsWithEnv enriches the entries of the map m whose elements are structs, slices or maps
+// from env variables below the key, e.g. CFG_UPSTREAMS_PRIMARY_HOST, or named by the key, e.g. CFG_TAGS_PRIMARY. The key is the part of the variable name up to
+// the next delimiter, unless the map already contains a key that matches a longer part in a different case,
+// e.g. the key primary_db of a config file. New keys are lower cased. Existing entries are enriched,
+// new entries are only added if an env variable has been found for them. The map is created if it is nil.
+// It reports whether at least one env variable has been found.
+// @m: The settable map value to enrich.
+// @prefix: The env variable name of the map.
+// @fieldPath: The path of m within the receiver, used for error reporting.
+// @secret: Whether m is a secret, see isSecret.
+func (e *envEnricher) enrichMapElementsWithEnv(m reflect.Value, prefix, fieldPath string, secret bool) bool {
+	keyPrefix := strings.ToUpper(prefix + e.envDelimiter)
+	existing := map[string]reflect.Value{}
+	if !m.IsNil() {
+		iter := m.MapRange()
+		for iter.Next() {
+			existing[strings.ToUpper(fmt.Sprint(iter.Key().Interface()))] = iter.Key()
+		}
+	}
+	keys := map[string]reflect.Value{}
+	for _, name := range e.env.names {
+		if !strings.HasPrefix(name, keyPrefix) || name == e.fileEnvName(prefix) {
+			continue
+		}
+		rest := name[len(keyPrefix):]
+		rawKey := ""
+		for k := range existing {
+			if (rest == k || strings.HasPrefix(rest, k+e.envDelimiter)) && len(k) > len(rawKey) {
+				rawKey = k
 			}
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			if osEnv == "" {
-				continue
+		}
+		if rawKey == "" {
+			rawKey = rest
+			if end := strings.Index(rest, e.envDelimiter); end >= 0 {
+				rawKey = rest[:end]
 			}
-			uit, err := strconv.ParseUint(osEnv, 10, 64)
+		}
+		if rawKey == "" {
+			continue
+		}
+		if _, ok := keys[rawKey]; ok {
+			continue
+		}
+		key, ok := existing[rawKey]
+		if !ok {
+			key = reflect.New(m.Type().Key()).Elem()
+			err := e.setValueFromString(key, strings.ToLower(rawKey))
 			if err != nil {
-				// could not parse uint
-				// so we skip this field
-				continue
-			}
-			if !f.IsValid() {
-				f.SetUint(uit)
+				e.errs = append(e.errs, &EnvParseError{
+					Name:  name,
+					Field: fmt.Sprintf("%s[%s]", fieldPath, strings.ToLower(rawKey)),
+					Err:   fmt.Errorf("invalid key %q: %w", strings.ToLower(rawKey), err),
+				})
 				continue
 			}
-			if f.CanSet() {
-				f.SetUint(uit)
+		}
+		keys[rawKey] = key
+	}
+
+	rawKeys := make([]string, 0, len(keys))
+	for rawKey := range keys {
+		rawKeys = append(rawKeys, rawKey)
+	}
+	// the keys are sorted, so the errors have a stable order
+	sort.Strings(rawKeys)
+	found := false
+	for _, rawKey := range rawKeys {
+		key := keys[rawKey]
+		// map elements are not addressable, so we enrich a copy
+		elem := reflect.New(m.Type().Elem()).Elem()
+		current := m.MapIndex(key)
+		if current.IsValid() {
+			elem.Set(current)
+		}
+		elemPath := fmt.Sprintf("%s[%v]", fieldPath, key.Interface())
+		errCount := len(e.errs)
+		if !e.enrichValueWithEnv(elem, e.prefixString(prefix, rawKey), elemPath, secret) {
+			continue
+		}
+		found = true
+		if !current.IsValid() && len(e.errs) > errCount && elem.IsZero() {
+			// only invalid env variables have been found
+			continue
+		}
+		if m.IsNil() {
+			m.Set(reflect.MakeMap(m.Type()))
+		}
+		m.SetMapIndex(key, elem)
+	}
+	return found
+}
+
+// setMapEntryFromString parses the key and the value according to the types of the map m and sets the entry.
+// The map is created if it is nil.
+// @m: The settable map value.
+// @rawKey: The string representation of the key.
+// @rawValue: The string representation of the value.
+func (l *Loader) setMapEntryFromString(m reflect.Value, rawKey, rawValue string) error {
+	key := reflect.New(m.Type().Key()).Elem()
+	err := l.setValueFromString(key, rawKey)
+	if err != nil {
+		return fmt.Errorf("invalid key %q: %w", rawKey, err)
+	}
+	value := reflect.New(m.Type().Elem()).Elem()
+	err = l.setValueFromString(value, rawValue)
+	if err != nil {
+		return err
+	}
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	m.SetMapIndex(key, value)
+	return nil
+}
+
+// isScalarType reports whether values of type t are set as a whole from a single string,
+// even though their kind is a struct, slice or map. This applies to the time types, url.URL
+// and all types whose pointer implements EnvDecoder or encoding.TextUnmarshaler.
+func isScalarType(t reflect.Type) bool {
+	if isTimeType(t) || t == urlType {
+		return true
+	}
+	pt := reflect.PtrTo(t)
+	return pt.Implements(envDecoderType) || pt.Implements(textUnmarshalerType)
+}
+
+// isElementType reports whether elements of type t can be parsed from a single string,
+// i.e. whether t is a scalar kind, a scalar type or a pointer to one of them.
+func isElementType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isScalarType(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Interface, reflect.Chan, reflect.Func:
+		return false
+	default:
+		return true
+	}
+}
+
+// isStringType reports whether values of type t can be set from a single env variable by setValueFromString,
+// i.e. whether t is an element type or a slice, array or map of element types.
+func isStringType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isScalarType(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return isElementType(t.Elem())
+	case reflect.Map:
+		return isElementType(t.Key()) && isElementType(t.Elem())
+	default:
+		return isElementType(t)
+	}
+}
+
+// unsupportedTypeError returns an error if values of type t can not be set from a single env variable, see isStringType.
+// The elements of slices, arrays and maps may still be set by their own env variables.
+func unsupportedTypeError(t reflect.Type) error {
+	if isStringType(t) {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return fmt.Errorf("unsupported type %s, use indexed variables", t)
+	case reflect.Map:
+		return fmt.Errorf("unsupported type %s, use a variable per key", t)
+	}
+	return fmt.Errorf("unsupported type %s", t)
+}
+
+// joinFieldPath appends the field name to the path of its parent.
+func joinFieldPath(parent, fieldName string) string {
+	if parent == "" {
+		return fieldName
+	}
+	return parent + "." + fieldName
+}
+
+// setValueFromString parses raw according to the type of v and sets the result.
+// EnvDecoder and encoding.TextUnmarshaler take precedence over the kind based parsing.
+// Kinds that are not supported are left untouched.
+// @v: The settable value to set.
+// @raw: The string representation of the value.
+func (l *Loader) setValueFromString(v reflect.Value, raw string) error {
+	if v.CanAddr() {
+		switch d := v.Addr().Interface().(type) {
+		case EnvDecoder:
+			return d.DecodeEnv(raw)
+		case encoding.TextUnmarshaler:
+			if !isTimeType(v.Type()) {
+				// time.Time implements encoding.TextUnmarshaler, but only supports RFC3339
+				return d.UnmarshalText([]byte(raw))
 			}
-		case reflect.Float32, reflect.Float64:
-			if osEnv == "" {
-				continue
+		}
+	}
+	if isTimeType(v.Type()) {
+		return l.setTimeFromString(v, raw)
+	}
+	if v.Type() == urlType {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return l.setValueFromString(v.Elem(), raw)
+		}
+		elem := reflect.New(v.Type().Elem())
+		err := l.setValueFromString(elem.Elem(), raw)
+		if err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice, reflect.Array:
+		if !isElementType(v.Type().Elem()) {
+			// slices of structs, maps and slices are not supported from a single env variable
+			return nil
+		}
+		parts := strings.Split(raw, l.envSliceDelimiter)
+		var sl reflect.Value
+		if v.Kind() == reflect.Array {
+			if len(parts) > v.Len() {
+				return fmt.Errorf("got %d elements, but the array has a length of %d", len(parts), v.Len())
 			}
-			fl, err := strconv.ParseFloat(osEnv, 64)
+			sl = reflect.New(v.Type()).Elem()
+		} else {
+			sl = reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		}
+		for i, part := range parts {
+			err := l.setValueFromString(sl.Index(i), part)
 			if err != nil {
-				// could not parse float
-				// so we skip this field
-				continue
+				return fmt.Errorf("index %d: %w", i, err)
 			}
-			if !f.IsValid() {
-				f.SetFloat(fl)
+		}
+		v.Set(sl)
+	case reflect.Map:
+		// entries are merged into the existing map
+		for _, entry := range strings.Split(raw, l.envSliceDelimiter) {
+			if entry == "" {
 				continue
 			}
-			if f.CanSet() {
-				f.SetFloat(fl)
-			}
-		case reflect.Bool:
-			if osEnv == "" {
-				continue
+			idx := strings.Index(entry, l.envMapKeyValueDelimiter)
+			if idx < 0 {
+				return fmt.Errorf("invalid map entry %q: missing %q", entry, l.envMapKeyValueDelimiter)
 			}
-			bl, err := strconv.ParseBool(osEnv)
+			err := l.setMapEntryFromString(v, entry[:idx], entry[idx+len(l.envMapKeyValueDelimiter):])
 			if err != nil {
-				// could not parse bool
-				// so we skip this field
-				continue
-			}
-			if !f.IsValid() {
-				f.SetBool(bl)
-				continue
-			}
-			if f.CanSet() {
-				f.SetBool(bl)
-			}
-		case reflect.String:
-			if osEnv == "" {
-				continue
-			}
-			if !f.IsValid() {
-				f.SetString(osEnv)
-				continue
-			}
-			if f.CanSet() {
-				f.SetString(osEnv)
-				continue
+				return err
 			}
 		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		in, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(in)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uit, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(uit)
+	case reflect.Float32, reflect.Float64:
+		fl, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(fl)
+	case reflect.Bool:
+		bl, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(bl)
+	case reflect.String:
+		v.SetString(raw)
 	}
+	return nil
 }
@@ -1,10 +1,21 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 type ExampleConfigA struct {
@@ -604,7 +615,7 @@ func Test_detectFormat(t *testing.T) {
 	tests := []struct {
 		name string
 		args args
-		want format
+		want Format
 	}{
 		{
 			name: "yaml",
@@ -655,7 +666,7 @@ func Test_loadAndParseFile(t *testing.T) {
 	type args struct {
 		filePath string
 		receiver interface{}
-		f        format
+		f        Format
 	}
 	tests := []struct {
 		name    string
@@ -768,7 +779,7 @@ func Test_loadAndParseFile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := loadAndParseFile(tt.args.filePath, tt.args.receiver, tt.args.f)
+			err := defaultLoader().loadAndParseFile(tt.args.filePath, tt.args.receiver, tt.args.f)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("loadAndParseFile() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -810,7 +821,7 @@ func Test_prefixString(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := prefixString(tt.args.prefix, tt.args.fieldName); got != tt.want {
+			if got := defaultLoader().prefixString(tt.args.prefix, tt.args.fieldName); got != tt.want {
 				t.Errorf("prefixString() = %v, want %v", got, tt.want)
 			}
 		})
@@ -828,6 +839,7 @@ func Test_readStructAndEnrichWithEnv(t *testing.T) {
 		preFunc  func() error
 		postFunc func() error
 		want     *ExampleConfigA
+		wantErr  bool
 	}{
 		{
 			name: "without env settings",
@@ -917,6 +929,66 @@ func Test_readStructAndEnrichWithEnv(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "with invalid env settings",
+			args: args{
+				st: &ExampleConfigA{
+					Name: "John",
+					Age:  30,
+					Children: ExampleConfigB{
+						Age: 30,
+					},
+				},
+				prefix: "envprefix",
+			},
+			preFunc: func() error {
+				os.Setenv("ENVPREFIX_NAME", "Johnathan")
+				os.Setenv("ENVPREFIX_AGE", "abc")
+				os.Setenv("ENVPREFIX_CHILDREN_AGE", "1.5")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("ENVPREFIX_NAME")
+				os.Unsetenv("ENVPREFIX_AGE")
+				os.Unsetenv("ENVPREFIX_CHILDREN_AGE")
+				return nil
+			},
+			want: &ExampleConfigA{
+				Name: "Johnathan",
+				Age:  30,
+				Children: ExampleConfigB{
+					Age: 30,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "with invalid env settings in lenient mode",
+			args: args{
+				st: &ExampleConfigA{
+					Name: "John",
+					Age:  30,
+				},
+				prefix: "envprefix",
+			},
+			preFunc: func() error {
+				EnvIgnoreParseErrors = true
+				os.Setenv("ENVPREFIX_NAME", "Johnathan")
+				os.Setenv("ENVPREFIX_AGE", "abc")
+				return nil
+			},
+			postFunc: func() error {
+				EnvIgnoreParseErrors = false
+				os.Unsetenv("ENVPREFIX_NAME")
+				os.Unsetenv("ENVPREFIX_AGE")
+				return nil
+			},
+			want: &ExampleConfigA{
+				Name: "Johnathan",
+				Age:  30,
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -924,7 +996,9 @@ func Test_readStructAndEnrichWithEnv(t *testing.T) {
 				tt.preFunc()
 			}
 
-			readStructAndEnrichWithEnv(tt.args.st, tt.args.prefix)
+			if err := defaultLoader().readStructAndEnrichWithEnv(tt.args.st, tt.args.prefix); (err != nil) != tt.wantErr {
+				t.Errorf("readStructAndEnrichWithEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
 			diff := cmp.Diff(tt.args.st, tt.want)
 			if diff != "" {
 				t.Errorf("readStructAndEnrichWithEnv() diff = %v\n", diff)
@@ -936,3 +1010,1218 @@ func Test_readStructAndEnrichWithEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestAutoloadAndEnrichConfigsWithEnvPrefix(t *testing.T) {
+	type args struct {
+		prefix    string
+		policy    SliceMergePolicy
+		receiver  interface{}
+		filePaths []string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		preFunc  func() error
+		postFunc func() error
+		want     interface{}
+		wantErr  bool
+	}{
+		{
+			name: "single file",
+			args: args{
+				prefix:    "aaa",
+				policy:    SliceReplace,
+				receiver:  &ExampleConfigA{},
+				filePaths: []string{".file/simple.yml"},
+			},
+			want: &ExampleConfigA{
+				Name:     "Simple Sam",
+				Age:      25,
+				Size:     1.87,
+				IsActive: true,
+				Uint:     8,
+				Hosts:    []string{"localhost", "127.0.0.1"},
+				Children: ExampleConfigB{
+					Name:     "Chris Sam",
+					Age:      3,
+					Size:     0.87,
+					IsActive: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "layered with replace policy",
+			args: args{
+				prefix:    "aaa",
+				policy:    SliceReplace,
+				receiver:  &ExampleConfigA{},
+				filePaths: []string{".file/simple.yml", ".file/override.yml", ".file/override.json"},
+			},
+			want: &ExampleConfigA{
+				Name:     "Override Olga",
+				Age:      25,
+				Size:     1.91,
+				IsActive: true,
+				Uint:     8,
+				Hosts:    []string{"10.0.0.2"},
+				Children: ExampleConfigB{
+					Name:     "Chris Sam",
+					Age:      4,
+					Size:     0.87,
+					IsActive: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "layered with append policy",
+			args: args{
+				prefix:    "aaa",
+				policy:    SliceAppend,
+				receiver:  &ExampleConfigA{},
+				filePaths: []string{".file/simple.yml", ".file/override.yml", ".file/override.json"},
+			},
+			want: &ExampleConfigA{
+				Name:     "Override Olga",
+				Age:      25,
+				Size:     1.91,
+				IsActive: true,
+				Uint:     8,
+				Hosts:    []string{"localhost", "127.0.0.1", "10.0.0.1", "10.0.0.2"},
+				Children: ExampleConfigB{
+					Name:     "Chris Sam",
+					Age:      4,
+					Size:     0.87,
+					IsActive: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "layered with env",
+			args: args{
+				prefix:    "aaa",
+				policy:    SliceReplace,
+				receiver:  &ExampleConfigA{},
+				filePaths: []string{".file/simple.yml", ".file/override.yml"},
+			},
+			preFunc: func() error {
+				os.Setenv("AAA_NAME", "emil")
+				os.Setenv("AAA_CHILDREN_AGE", "5")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("AAA_NAME")
+				os.Unsetenv("AAA_CHILDREN_AGE")
+				return nil
+			},
+			want: &ExampleConfigA{
+				Name:     "emil",
+				Age:      25,
+				Size:     1.87,
+				IsActive: true,
+				Uint:     8,
+				Hosts:    []string{"10.0.0.1"},
+				Children: ExampleConfigB{
+					Name:     "Chris Sam",
+					Age:      5,
+					Size:     0.87,
+					IsActive: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported type",
+			args: args{
+				prefix:    "aaa",
+				policy:    SliceReplace,
+				receiver:  &ExampleConfigA{},
+				filePaths: []string{".file/simple.yml", ".file/simple.usu"},
+			},
+			want: &ExampleConfigA{
+				Name:     "Simple Sam",
+				Age:      25,
+				Size:     1.87,
+				IsActive: true,
+				Uint:     8,
+				Hosts:    []string{"localhost", "127.0.0.1"},
+				Children: ExampleConfigB{
+					Name:     "Chris Sam",
+					Age:      3,
+					Size:     0.87,
+					IsActive: true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "non pointer receiver",
+			args: args{
+				prefix:    "aaa",
+				policy:    SliceReplace,
+				receiver:  ExampleConfigA{},
+				filePaths: []string{".file/simple.yml"},
+			},
+			want:    ExampleConfigA{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.preFunc != nil {
+				tt.preFunc()
+			}
+
+			if err := AutoloadAndEnrichConfigsWithEnvPrefix(tt.args.prefix, tt.args.policy, tt.args.receiver, tt.args.filePaths...); (err != nil) != tt.wantErr {
+				t.Errorf("AutoloadAndEnrichConfigsWithEnvPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			diff := cmp.Diff(tt.args.receiver, tt.want)
+			if diff != "" {
+				t.Errorf("AutoloadAndEnrichConfigsWithEnvPrefix() diff = %v", diff)
+			}
+
+			if tt.postFunc != nil {
+				tt.postFunc()
+			}
+		})
+	}
+}
+
+func Test_envName(t *testing.T) {
+	type example struct {
+		DatabaseURL string
+		Renamed     string `env:"DATABASE_URL"`
+		NoPrefix    string `env:"DATABASE_URL,noprefix"`
+		NoName      string `env:",noprefix"`
+		Excluded    string `env:"-"`
+	}
+	type args struct {
+		prefix string
+		field  string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     string
+		wantSkip bool
+	}{
+		{
+			name: "without tag",
+			args: args{
+				prefix: "cfg",
+				field:  "DatabaseURL",
+			},
+			want: "CFG_DATABASEURL",
+		},
+		{
+			name: "with tag",
+			args: args{
+				prefix: "cfg",
+				field:  "Renamed",
+			},
+			want: "CFG_DATABASE_URL",
+		},
+		{
+			name: "with tag and noprefix",
+			args: args{
+				prefix: "cfg",
+				field:  "NoPrefix",
+			},
+			want: "DATABASE_URL",
+		},
+		{
+			name: "noprefix without name",
+			args: args{
+				prefix: "cfg",
+				field:  "NoName",
+			},
+			want: "NONAME",
+		},
+		{
+			name: "excluded",
+			args: args{
+				prefix: "cfg",
+				field:  "Excluded",
+			},
+			want:     "",
+			wantSkip: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, _ := reflect.TypeOf(example{}).FieldByName(tt.args.field)
+			got, gotSkip := defaultLoader().envName(tt.args.prefix, field)
+			if got != tt.want {
+				t.Errorf("envName() got = %v, want %v", got, tt.want)
+			}
+			if gotSkip != tt.wantSkip {
+				t.Errorf("envName() gotSkip = %v, want %v", gotSkip, tt.wantSkip)
+			}
+		})
+	}
+}
+
+func Test_readStructAndEnrichWithEnvTags(t *testing.T) {
+	type database struct {
+		Host string `env:"HOSTNAME"`
+		Port int
+	}
+	type example struct {
+		DatabaseURL string   `env:"DATABASE_URL,noprefix"`
+		Secret      string   `env:"-"`
+		Database    database `env:"DB"`
+	}
+
+	os.Setenv("DATABASE_URL", "postgres://localhost")
+	os.Setenv("CFG_SECRET", "leaked")
+	os.Setenv("CFG_DB_HOSTNAME", "db.local")
+	os.Setenv("CFG_DB_PORT", "5432")
+	defer func() {
+		os.Unsetenv("DATABASE_URL")
+		os.Unsetenv("CFG_SECRET")
+		os.Unsetenv("CFG_DB_HOSTNAME")
+		os.Unsetenv("CFG_DB_PORT")
+	}()
+
+	got := &example{Secret: "from file"}
+	defaultLoader().readStructAndEnrichWithEnv(got, "cfg")
+	want := &example{
+		DatabaseURL: "postgres://localhost",
+		Secret:      "from file",
+		Database: database{
+			Host: "db.local",
+			Port: 5432,
+		},
+	}
+	diff := cmp.Diff(got, want)
+	if diff != "" {
+		t.Errorf("readStructAndEnrichWithEnv() diff = %v\n", diff)
+	}
+}
+
+func Test_readStructAndEnrichWithEnvErrors(t *testing.T) {
+	type server struct {
+		Port uint16
+	}
+	type example struct {
+		Server  server
+		Enabled bool
+	}
+
+	os.Setenv("CFG_SERVER_PORT", "70000")
+	os.Setenv("CFG_ENABLED", "yes")
+	defer func() {
+		os.Unsetenv("CFG_SERVER_PORT")
+		os.Unsetenv("CFG_ENABLED")
+	}()
+
+	err := defaultLoader().readStructAndEnrichWithEnv(&example{}, "cfg")
+	envErrs, ok := err.(EnvErrors)
+	if !ok {
+		t.Fatalf("readStructAndEnrichWithEnv() error = %T, want EnvErrors", err)
+	}
+	want := EnvErrors{
+		{Name: "CFG_SERVER_PORT", Field: "Server.Port", Value: "70000"},
+		{Name: "CFG_ENABLED", Field: "Enabled", Value: "yes"},
+	}
+	diff := cmp.Diff(envErrs, want, cmpopts.IgnoreFields(EnvParseError{}, "Err"))
+	if diff != "" {
+		t.Errorf("readStructAndEnrichWithEnv() diff = %v\n", diff)
+	}
+	for _, e := range envErrs {
+		if !errors.Is(e, strconv.ErrRange) && !errors.Is(e, strconv.ErrSyntax) {
+			t.Errorf("readStructAndEnrichWithEnv() error = %v, want wrapped strconv error", e)
+		}
+	}
+}
+
+func Test_readStructAndEnrichWithEnvMaps(t *testing.T) {
+	type example struct {
+		Labels map[string]string
+		Limits map[string]int
+		Ports  map[int]bool
+	}
+	type args struct {
+		st     *example
+		prefix string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		preFunc  func() error
+		postFunc func() error
+		want     *example
+		wantErr  bool
+	}{
+		{
+			name: "single variable into nil map",
+			args: args{
+				st:     &example{},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_LABELS", "team=core;tier=1")
+				os.Setenv("CFG_PORTS", "80=true;443=false")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_LABELS")
+				os.Unsetenv("CFG_PORTS")
+				return nil
+			},
+			want: &example{
+				Labels: map[string]string{"team": "core", "tier": "1"},
+				Ports:  map[int]bool{80: true, 443: false},
+			},
+			wantErr: false,
+		},
+		{
+			name: "single variable merges into existing map",
+			args: args{
+				st: &example{
+					Labels: map[string]string{"team": "edge", "zone": "eu"},
+				},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_LABELS", "team=core")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_LABELS")
+				return nil
+			},
+			want: &example{
+				Labels: map[string]string{"team": "core", "zone": "eu"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "per key variables",
+			args: args{
+				st: &example{
+					Limits: map[string]int{"maxConns": 10},
+				},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_LABELS", "team=core")
+				os.Setenv("CFG_LABELS_TEAM", "platform")
+				os.Setenv("CFG_LIMITS_MAXCONNS", "100")
+				os.Setenv("CFG_LIMITS_CPU", "2")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_LABELS")
+				os.Unsetenv("CFG_LABELS_TEAM")
+				os.Unsetenv("CFG_LIMITS_MAXCONNS")
+				os.Unsetenv("CFG_LIMITS_CPU")
+				return nil
+			},
+			want: &example{
+				Labels: map[string]string{"team": "platform"},
+				Limits: map[string]int{"maxConns": 100, "cpu": 2},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid values",
+			args: args{
+				st:     &example{},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_LABELS", "team")
+				os.Setenv("CFG_LIMITS_CPU", "two")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_LABELS")
+				os.Unsetenv("CFG_LIMITS_CPU")
+				return nil
+			},
+			want:    &example{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.preFunc != nil {
+				tt.preFunc()
+			}
+
+			if err := defaultLoader().readStructAndEnrichWithEnv(tt.args.st, tt.args.prefix); (err != nil) != tt.wantErr {
+				t.Errorf("readStructAndEnrichWithEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			diff := cmp.Diff(tt.args.st, tt.want)
+			if diff != "" {
+				t.Errorf("readStructAndEnrichWithEnv() diff = %v\n", diff)
+			}
+
+			if tt.postFunc != nil {
+				tt.postFunc()
+			}
+		})
+	}
+}
+
+func Test_readStructAndEnrichWithEnvPointers(t *testing.T) {
+	type tls struct {
+		Enabled  bool
+		CertFile string
+	}
+	type example struct {
+		Timeout *int
+		Name    *string
+		TLS     *tls
+		Proxy   *tls
+	}
+	intPtr := func(i int) *int { return &i }
+	strPtr := func(s string) *string { return &s }
+	type args struct {
+		st     *example
+		prefix string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		preFunc  func() error
+		postFunc func() error
+		want     *example
+		wantErr  bool
+	}{
+		{
+			name: "without env settings",
+			args: args{
+				st:     &example{},
+				prefix: "cfg",
+			},
+			want:    &example{},
+			wantErr: false,
+		},
+		{
+			name: "allocate nil pointers",
+			args: args{
+				st:     &example{},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_TIMEOUT", "30")
+				os.Setenv("CFG_TLS_ENABLED", "false")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_TIMEOUT")
+				os.Unsetenv("CFG_TLS_ENABLED")
+				return nil
+			},
+			want: &example{
+				Timeout: intPtr(30),
+				TLS:     &tls{Enabled: false},
+			},
+			wantErr: false,
+		},
+		{
+			name: "existing pointers",
+			args: args{
+				st: &example{
+					Name: strPtr("file"),
+					TLS:  &tls{Enabled: true, CertFile: "cert.pem"},
+				},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_NAME", "env")
+				os.Setenv("CFG_TLS_CERTFILE", "other.pem")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_NAME")
+				os.Unsetenv("CFG_TLS_CERTFILE")
+				return nil
+			},
+			want: &example{
+				Name: strPtr("env"),
+				TLS:  &tls{Enabled: true, CertFile: "other.pem"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid value",
+			args: args{
+				st:     &example{},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_TIMEOUT", "abc")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_TIMEOUT")
+				return nil
+			},
+			want:    &example{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.preFunc != nil {
+				tt.preFunc()
+			}
+
+			if err := defaultLoader().readStructAndEnrichWithEnv(tt.args.st, tt.args.prefix); (err != nil) != tt.wantErr {
+				t.Errorf("readStructAndEnrichWithEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			diff := cmp.Diff(tt.args.st, tt.want)
+			if diff != "" {
+				t.Errorf("readStructAndEnrichWithEnv() diff = %v\n", diff)
+			}
+
+			if tt.postFunc != nil {
+				tt.postFunc()
+			}
+		})
+	}
+}
+
+type logLevel int
+
+const (
+	logLevelInfo logLevel = iota
+	logLevelDebug
+)
+
+func (l *logLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "info":
+		*l = logLevelInfo
+	case "debug":
+		*l = logLevelDebug
+	default:
+		return fmt.Errorf("unknown log level %q", text)
+	}
+	return nil
+}
+
+type upperString string
+
+func (u *upperString) DecodeEnv(value string) error {
+	*u = upperString(strings.ToUpper(value))
+	return nil
+}
+
+// envDecoderAndTextUnmarshaler implements both interfaces, EnvDecoder must win.
+type envDecoderAndTextUnmarshaler string
+
+func (e *envDecoderAndTextUnmarshaler) DecodeEnv(value string) error {
+	*e = envDecoderAndTextUnmarshaler("env:" + value)
+	return nil
+}
+
+func (e *envDecoderAndTextUnmarshaler) UnmarshalText(text []byte) error {
+	*e = envDecoderAndTextUnmarshaler("text:" + string(text))
+	return nil
+}
+
+func Test_readStructAndEnrichWithEnvDecoders(t *testing.T) {
+	type example struct {
+		Level    logLevel
+		Levels   map[string]logLevel
+		IP       net.IP
+		Endpoint *url.URL
+		Name     upperString
+		Both     envDecoderAndTextUnmarshaler
+	}
+	type args struct {
+		st     *example
+		prefix string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		preFunc  func() error
+		postFunc func() error
+		want     *example
+		wantErr  bool
+	}{
+		{
+			name: "decoders",
+			args: args{
+				st:     &example{},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_LEVEL", "debug")
+				os.Setenv("CFG_LEVELS_HTTP", "debug")
+				os.Setenv("CFG_IP", "10.0.0.1")
+				os.Setenv("CFG_ENDPOINT", "https://example.com/api")
+				os.Setenv("CFG_NAME", "sam")
+				os.Setenv("CFG_BOTH", "value")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_LEVEL")
+				os.Unsetenv("CFG_LEVELS_HTTP")
+				os.Unsetenv("CFG_IP")
+				os.Unsetenv("CFG_ENDPOINT")
+				os.Unsetenv("CFG_NAME")
+				os.Unsetenv("CFG_BOTH")
+				return nil
+			},
+			want: &example{
+				Level:    logLevelDebug,
+				Levels:   map[string]logLevel{"http": logLevelDebug},
+				IP:       net.ParseIP("10.0.0.1"),
+				Endpoint: &url.URL{Scheme: "https", Host: "example.com", Path: "/api"},
+				Name:     "SAM",
+				Both:     "env:value",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid values",
+			args: args{
+				st:     &example{},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_LEVEL", "trace")
+				os.Setenv("CFG_IP", "not an ip")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_LEVEL")
+				os.Unsetenv("CFG_IP")
+				return nil
+			},
+			want:    &example{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.preFunc != nil {
+				tt.preFunc()
+			}
+
+			if err := defaultLoader().readStructAndEnrichWithEnv(tt.args.st, tt.args.prefix); (err != nil) != tt.wantErr {
+				t.Errorf("readStructAndEnrichWithEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			diff := cmp.Diff(tt.args.st, tt.want)
+			if diff != "" {
+				t.Errorf("readStructAndEnrichWithEnv() diff = %v\n", diff)
+			}
+
+			if tt.postFunc != nil {
+				tt.postFunc()
+			}
+		})
+	}
+}
+
+func Test_setValueFromStringSlices(t *testing.T) {
+	type args struct {
+		v   interface{}
+		raw string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "strings",
+			args: args{
+				v:   &[]string{"old"},
+				raw: "a;b",
+			},
+			want:    []string{"a", "b"},
+			wantErr: false,
+		},
+		{
+			name: "ints",
+			args: args{
+				v:   new([]int),
+				raw: "80;443",
+			},
+			want:    []int{80, 443},
+			wantErr: false,
+		},
+		{
+			name: "floats",
+			args: args{
+				v:   new([]float64),
+				raw: "0.5;1.5",
+			},
+			want:    []float64{0.5, 1.5},
+			wantErr: false,
+		},
+		{
+			name: "bools",
+			args: args{
+				v:   new([]bool),
+				raw: "true;false",
+			},
+			want:    []bool{true, false},
+			wantErr: false,
+		},
+		{
+			name: "durations",
+			args: args{
+				v:   new([]time.Duration),
+				raw: "1s;1m",
+			},
+			want:    []time.Duration{time.Second, time.Minute},
+			wantErr: false,
+		},
+		{
+			name: "array",
+			args: args{
+				v:   new([3]uint8),
+				raw: "1;2",
+			},
+			want:    [3]uint8{1, 2, 0},
+			wantErr: false,
+		},
+		{
+			name: "array too short",
+			args: args{
+				v:   new([1]uint8),
+				raw: "1;2",
+			},
+			want:    [1]uint8{},
+			wantErr: true,
+		},
+		{
+			name: "invalid element",
+			args: args{
+				v:   &[]int{1},
+				raw: "80;http",
+			},
+			want:    []int{1},
+			wantErr: true,
+		},
+		{
+			name: "unsupported element",
+			args: args{
+				v:   &[]ExampleConfigB{{Name: "a"}},
+				raw: "a;b",
+			},
+			want:    []ExampleConfigB{{Name: "a"}},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := reflect.ValueOf(tt.args.v).Elem()
+			if err := defaultLoader().setValueFromString(v, tt.args.raw); (err != nil) != tt.wantErr {
+				t.Errorf("setValueFromString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			diff := cmp.Diff(v.Interface(), tt.want)
+			if diff != "" {
+				t.Errorf("setValueFromString() diff = %v", diff)
+			}
+		})
+	}
+}
+
+func Test_readStructAndEnrichWithEnvSliceErrors(t *testing.T) {
+	type example struct {
+		Ports []int
+	}
+	os.Setenv("CFG_PORTS", "80;http;443")
+	defer os.Unsetenv("CFG_PORTS")
+
+	err := defaultLoader().readStructAndEnrichWithEnv(&example{}, "cfg")
+	envErrs, ok := err.(EnvErrors)
+	if !ok || len(envErrs) != 1 {
+		t.Fatalf("readStructAndEnrichWithEnv() error = %v, want one EnvParseError", err)
+	}
+	if !strings.HasPrefix(envErrs[0].Err.Error(), "index 1: ") {
+		t.Errorf("readStructAndEnrichWithEnv() error = %v, want error for index 1", envErrs[0].Err)
+	}
+}
+
+func Test_readStructAndEnrichWithEnvIndexedSlices(t *testing.T) {
+	type upstream struct {
+		Host string
+		Port int
+	}
+	type example struct {
+		Upstreams []upstream
+		Backups   []*upstream
+		Fixed     [1]upstream
+	}
+	type args struct {
+		st     *example
+		prefix string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		preFunc  func() error
+		postFunc func() error
+		want     *example
+		wantErr  bool
+	}{
+		{
+			name: "merge into existing elements",
+			args: args{
+				st: &example{
+					Upstreams: []upstream{
+						{Host: "a.local", Port: 80},
+						{Host: "b.local", Port: 80},
+					},
+				},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_UPSTREAMS_1_PORT", "8080")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_UPSTREAMS_1_PORT")
+				return nil
+			},
+			want: &example{
+				Upstreams: []upstream{
+					{Host: "a.local", Port: 80},
+					{Host: "b.local", Port: 8080},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "grow slices",
+			args: args{
+				st: &example{
+					Upstreams: []upstream{
+						{Host: "a.local", Port: 80},
+					},
+				},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_UPSTREAMS_0_HOST", "z.local")
+				os.Setenv("CFG_UPSTREAMS_2_HOST", "c.local")
+				os.Setenv("CFG_UPSTREAMS_2_PORT", "443")
+				os.Setenv("CFG_BACKUPS_0_HOST", "backup.local")
+				os.Setenv("CFG_FIXED_0_PORT", "22")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_UPSTREAMS_0_HOST")
+				os.Unsetenv("CFG_UPSTREAMS_2_HOST")
+				os.Unsetenv("CFG_UPSTREAMS_2_PORT")
+				os.Unsetenv("CFG_BACKUPS_0_HOST")
+				os.Unsetenv("CFG_FIXED_0_PORT")
+				return nil
+			},
+			want: &example{
+				Upstreams: []upstream{
+					{Host: "z.local", Port: 80},
+					{},
+					{Host: "c.local", Port: 443},
+				},
+				Backups: []*upstream{
+					{Host: "backup.local"},
+				},
+				Fixed: [1]upstream{
+					{Port: 22},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "array index out of range",
+			args: args{
+				st:     &example{},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_FIXED_1_PORT", "22")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_FIXED_1_PORT")
+				return nil
+			},
+			want:    &example{},
+			wantErr: true,
+		},
+		{
+			name: "slice index exceeds the number of indexes",
+			args: args{
+				st: &example{
+					Upstreams: []upstream{
+						{Host: "a.local", Port: 80},
+					},
+				},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_UPSTREAMS_1_HOST", "b.local")
+				os.Setenv("CFG_UPSTREAMS_99999999999_HOST", "c.local")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_UPSTREAMS_1_HOST")
+				os.Unsetenv("CFG_UPSTREAMS_99999999999_HOST")
+				return nil
+			},
+			want: &example{
+				Upstreams: []upstream{
+					{Host: "a.local", Port: 80},
+					{Host: "b.local"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "single variable for a slice of structs",
+			args: args{
+				st:     &example{},
+				prefix: "cfg",
+			},
+			preFunc: func() error {
+				os.Setenv("CFG_UPSTREAMS", "a.local")
+				return nil
+			},
+			postFunc: func() error {
+				os.Unsetenv("CFG_UPSTREAMS")
+				return nil
+			},
+			want:    &example{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.preFunc != nil {
+				tt.preFunc()
+			}
+
+			if err := defaultLoader().readStructAndEnrichWithEnv(tt.args.st, tt.args.prefix); (err != nil) != tt.wantErr {
+				t.Errorf("readStructAndEnrichWithEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			diff := cmp.Diff(tt.args.st, tt.want)
+			if diff != "" {
+				t.Errorf("readStructAndEnrichWithEnv() diff = %v\n", diff)
+			}
+
+			if tt.postFunc != nil {
+				tt.postFunc()
+			}
+		})
+	}
+}
+
+func Test_readStructAndEnrichWithEnvFiles(t *testing.T) {
+	type database struct {
+		User     string
+		Password string
+		Port     int
+	}
+	type example struct {
+		Database database
+		Token    *string
+		Labels   map[string]string
+	}
+	dir := t.TempDir()
+	writeSecret := func(name, content string) string {
+		filePath := filepath.Join(dir, name)
+		err := ioutil.WriteFile(filePath, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return filePath
+	}
+	password := writeSecret("db_password", "s3cr3t\n")
+	token := writeSecret("token", "abc\r\n")
+	port := writeSecret("db_port", "not a port\n")
+	labels := writeSecret("labels", "team=core;tier=1\n")
+
+	tests := []struct {
+		name    string
+		environ []string
+		want    *example
+		wantErr bool
+	}{
+		{
+			name:    "value from file",
+			environ: []string{"CFG_DATABASE_USER=sam", "CFG_DATABASE_PASSWORD_FILE=" + password},
+			want:    &example{Database: database{User: "sam", Password: "s3cr3t"}},
+		},
+		{
+			name:    "pointer and map from file",
+			environ: []string{"CFG_TOKEN_FILE=" + token, "CFG_LABELS_FILE=" + labels},
+			want: &example{
+				Token:  func() *string { s := "abc"; return &s }(),
+				Labels: map[string]string{"team": "core", "tier": "1"},
+			},
+		},
+		{
+			name:    "empty file variable",
+			environ: []string{"CFG_DATABASE_PASSWORD=plain", "CFG_DATABASE_PASSWORD_FILE="},
+			want:    &example{Database: database{Password: "plain"}},
+		},
+		{
+			name:    "both set",
+			environ: []string{"CFG_DATABASE_PASSWORD=plain", "CFG_DATABASE_PASSWORD_FILE=" + password},
+			want:    &example{},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			environ: []string{"CFG_DATABASE_PASSWORD_FILE=" + filepath.Join(dir, "missing")},
+			want:    &example{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid value in file",
+			environ: []string{"CFG_DATABASE_PORT_FILE=" + port},
+			want:    &example{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &example{}
+			l := NewLoader(WithEnviron(func() []string { return tt.environ }))
+			if err := l.readStructAndEnrichWithEnv(got, "cfg"); (err != nil) != tt.wantErr {
+				t.Errorf("readStructAndEnrichWithEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			diff := cmp.Diff(got, tt.want)
+			if diff != "" {
+				t.Errorf("readStructAndEnrichWithEnv() diff = %v\n", diff)
+			}
+		})
+	}
+}
+
+func Test_readStructAndEnrichWithEnvMapElements(t *testing.T) {
+	type upstream struct {
+		Host string
+		Port int
+	}
+	type example struct {
+		Upstreams map[string]upstream
+		Backups   map[string]*upstream
+		Tags      map[string][]string
+	}
+	tests := []struct {
+		name    string
+		st      *example
+		environ []string
+		want    *example
+		wantErr bool
+	}{
+		{
+			name:    "new keys",
+			st:      &example{},
+			environ: []string{"CFG_UPSTREAMS_FOO_HOST=h", "CFG_UPSTREAMS_FOO_PORT=80", "CFG_BACKUPS_BAR_HOST=b", "CFG_UPSTREAMS_BAZ="},
+			want: &example{
+				Upstreams: map[string]upstream{"foo": {Host: "h", Port: 80}},
+				Backups:   map[string]*upstream{"bar": {Host: "b"}},
+			},
+		},
+		{
+			name: "existing keys",
+			st: &example{
+				Upstreams: map[string]upstream{"Primary_DB": {Host: "db", Port: 5432}, "cache": {Host: "c"}},
+			},
+			environ: []string{"CFG_UPSTREAMS_PRIMARY_DB_PORT=6432", "CFG_UPSTREAMS_CACHE_PORT=6379"},
+			want: &example{
+				Upstreams: map[string]upstream{"Primary_DB": {Host: "db", Port: 6432}, "cache": {Host: "c", Port: 6379}},
+			},
+		},
+		{
+			name:    "slices by key",
+			st:      &example{},
+			environ: []string{"CFG_TAGS_A=x;y"},
+			want: &example{
+				Tags: map[string][]string{"a": {"x", "y"}},
+			},
+		},
+		{
+			name:    "invalid values",
+			st:      &example{},
+			environ: []string{"CFG_UPSTREAMS_FOO_PORT=eighty"},
+			want:    &example{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLoader(WithEnviron(func() []string { return tt.environ }))
+			if err := l.readStructAndEnrichWithEnv(tt.st, "cfg"); (err != nil) != tt.wantErr {
+				t.Errorf("readStructAndEnrichWithEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			diff := cmp.Diff(tt.st, tt.want)
+			if diff != "" {
+				t.Errorf("readStructAndEnrichWithEnv() diff = %v\n", diff)
+			}
+		})
+	}
+}
+
+func Test_readStructAndEnrichWithEnvFilesHidesContent(t *testing.T) {
+	type example struct {
+		Port int
+	}
+	filePath := filepath.Join(t.TempDir(), "port")
+	err := ioutil.WriteFile(filePath, []byte("s3cr3t\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLoader(WithEnviron(func() []string { return []string{"CFG_PORT_FILE=" + filePath} }))
+	err = l.readStructAndEnrichWithEnv(&example{}, "cfg")
+	if err == nil {
+		t.Fatalf("readStructAndEnrichWithEnv() expected an error")
+	}
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("readStructAndEnrichWithEnv() error = %v, must not contain the content of the file", err)
+	}
+	if !strings.Contains(err.Error(), "CFG_PORT_FILE") {
+		t.Errorf("readStructAndEnrichWithEnv() error = %v, want the name of the file variable", err)
+	}
+	if !errors.Is(err.(EnvErrors)[0], strconv.ErrSyntax) {
+		t.Errorf("readStructAndEnrichWithEnv() error = %v, want wrapped strconv error", err)
+	}
+}
+
+func Test_readStructAndEnrichWithEnvHidesSecrets(t *testing.T) {
+	type example struct {
+		Pin  int            `secret:"true"`
+		Keys map[string]int `secret:"true"`
+		Port int
+	}
+	l := NewLoader(WithEnviron(func() []string {
+		return []string{"CFG_PIN=s3cr3t", "CFG_KEYS_A=k3y", "CFG_PORT=abc"}
+	}))
+	err := l.readStructAndEnrichWithEnv(&example{}, "cfg")
+	if err == nil {
+		t.Fatalf("readStructAndEnrichWithEnv() expected an error")
+	}
+	for _, secret := range []string{"s3cr3t", "k3y"} {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("readStructAndEnrichWithEnv() error = %v, must not contain the secret %q", err, secret)
+		}
+	}
+	if !strings.Contains(err.Error(), `"abc"`) {
+		t.Errorf("readStructAndEnrichWithEnv() error = %v, want the value of the field that is not secret", err)
+	}
+	for _, envErr := range err.(EnvErrors) {
+		if envErr.Field != "Port" && envErr.Value != RedactedValue {
+			t.Errorf("EnvParseError.Value = %q, want %q", envErr.Value, RedactedValue)
+		}
+		if !errors.Is(envErr, strconv.ErrSyntax) {
+			t.Errorf("EnvParseError = %v, want wrapped strconv error", envErr)
+		}
+	}
+}
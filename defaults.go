@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// defaultTag is the struct tag used to define the default value of a field.
+// The value is converted with the same rules as env variables, slices are separated by the env slice delimiter of the Loader.
+const defaultTag = "default"
+
+// DefaultsMode defines when the values of the default tags are applied.
+type DefaultsMode int
+
+const (
+	// DefaultsBeforeParse applies the defaults before the config files are parsed,
+	// so every value defined in a config file overrides the default.
+	DefaultsBeforeParse DefaultsMode = iota
+	// DefaultsOnZero applies the defaults after the config files are parsed to all fields that still hold the zero value.
+	DefaultsOnZero
+)
+
+// DefaultsApplyMode defines when the values of the default tags are applied.
+//
+// Deprecated: use the WithDefaultsMode option of NewLoader.
+var DefaultsApplyMode = DefaultsBeforeParse
+
+// applyDefaults walks through the struct st and sets the value of the default tag for each field that holds the zero value.
+// Fields that are already set, e.g. by pre-populating the receiver, are never overridden.
+// @st: The pointer to the struct to apply the defaults to.
+func (l *Loader) applyDefaults(st interface{}) error {
+	val := reflect.ValueOf(st)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	return l.applyStructDefaults(val, "")
+}
+
+// applyStructDefaults is the recursive part of applyDefaults.
+// @val: The struct value to apply the defaults to.
+// @fieldPath: The path of val within the receiver, used for error reporting.
+func (l *Loader) applyStructDefaults(val reflect.Value, fieldPath string) error {
+	for i := 0; i < val.NumField(); i++ {
+		f := val.Field(i)
+		if !f.CanSet() {
+			// unexported field
+			continue
+		}
+		field := val.Type().Field(i)
+		path := joinFieldPath(fieldPath, field.Name)
+		if f.Kind() == reflect.Struct && !isScalarType(f.Type()) {
+			err := l.applyStructDefaults(f, path)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		def, ok := field.Tag.Lookup(defaultTag)
+		if !ok || !f.IsZero() {
+			continue
+		}
+		err := l.setValueFromString(f, def)
+		if err != nil {
+			return fmt.Errorf("invalid default value %q for field %s: %w", def, path, err)
+		}
+		l.rec.record(path, Source{Kind: SourceDefault})
+	}
+	return nil
+}
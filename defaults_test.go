@@ -0,0 +1,169 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type defaultsExample struct {
+	Name    string   `default:"Simple Sam"`
+	Port    int      `default:"8080"`
+	Ratio   float64  `default:"0.5"`
+	Enabled bool     `default:"true"`
+	Hosts   []string `default:"localhost;127.0.0.1"`
+	NoTag   string
+	Server  defaultsServerExample
+}
+
+type defaultsServerExample struct {
+	Address string `default:"0.0.0.0"`
+	Port    uint16 `default:"443"`
+}
+
+type defaultsInvalidExample struct {
+	Server struct {
+		Port int `default:"abc"`
+	}
+}
+
+func Test_applyDefaults(t *testing.T) {
+	type args struct {
+		st interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "empty receiver",
+			args: args{
+				st: &defaultsExample{},
+			},
+			want: &defaultsExample{
+				Name:    "Simple Sam",
+				Port:    8080,
+				Ratio:   0.5,
+				Enabled: true,
+				Hosts:   []string{"localhost", "127.0.0.1"},
+				Server: defaultsServerExample{
+					Address: "0.0.0.0",
+					Port:    443,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "pre-populated receiver",
+			args: args{
+				st: &defaultsExample{
+					Name:  "John",
+					Hosts: []string{"example.com"},
+					Server: defaultsServerExample{
+						Port: 8443,
+					},
+				},
+			},
+			want: &defaultsExample{
+				Name:    "John",
+				Port:    8080,
+				Ratio:   0.5,
+				Enabled: true,
+				Hosts:   []string{"example.com"},
+				Server: defaultsServerExample{
+					Address: "0.0.0.0",
+					Port:    8443,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid default",
+			args: args{
+				st: &defaultsInvalidExample{},
+			},
+			want:    &defaultsInvalidExample{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := defaultLoader().applyDefaults(tt.args.st); (err != nil) != tt.wantErr {
+				t.Errorf("applyDefaults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			diff := cmp.Diff(tt.args.st, tt.want)
+			if diff != "" {
+				t.Errorf("applyDefaults() diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestAutoloadAndEnrichConfigWithDefaults(t *testing.T) {
+	type example struct {
+		Name     string `default:"Default Dan"`
+		Age      int    `default:"42"`
+		IsActive bool   `default:"true"`
+		Uint     int64  `default:"1"`
+		Email    string `default:"dan@example.com"`
+	}
+	tests := []struct {
+		name string
+		mode DefaultsMode
+		file string
+		want *example
+	}{
+		{
+			name: "before parse",
+			mode: DefaultsBeforeParse,
+			file: ".file/defaults.yml",
+			want: &example{
+				Name:     "Simple Sam",
+				Age:      25,
+				IsActive: false,
+				Uint:     1,
+				Email:    "dan@example.com",
+			},
+		},
+		{
+			name: "on zero",
+			mode: DefaultsOnZero,
+			file: ".file/defaults.yml",
+			want: &example{
+				Name:     "Simple Sam",
+				Age:      25,
+				IsActive: true,
+				Uint:     1,
+				Email:    "dan@example.com",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			DefaultsApplyMode = tt.mode
+			defer func() {
+				DefaultsApplyMode = DefaultsBeforeParse
+			}()
+
+			got := &example{}
+			if err := AutoloadAndEnrichConfig(tt.file, got); err != nil {
+				t.Errorf("AutoloadAndEnrichConfig() error = %v", err)
+			}
+			diff := cmp.Diff(got, tt.want)
+			if diff != "" {
+				t.Errorf("AutoloadAndEnrichConfig() diff = %v", diff)
+			}
+
+			got = &example{}
+			if err := AutoloadAndEnrichConfigs(got, tt.file); err != nil {
+				t.Errorf("AutoloadAndEnrichConfigs() error = %v", err)
+			}
+			diff = cmp.Diff(got, tt.want)
+			if diff != "" {
+				t.Errorf("AutoloadAndEnrichConfigs() diff = %v", diff)
+			}
+		})
+	}
+}
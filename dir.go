@@ -0,0 +1,106 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WithDirRecursive defines whether LoadDir descends into sub directories. The default is false.
+func WithDirRecursive(recursive bool) Option {
+	return func(l *Loader) {
+		l.dirRecursive = recursive
+	}
+}
+
+// WithDirPattern restricts the files loaded by LoadDir to those whose base name matches the pattern,
+// see filepath.Match for the syntax. By default all files of the accepted formats are loaded.
+func WithDirPattern(pattern string) Option {
+	return func(l *Loader) {
+		l.dirPattern = pattern
+	}
+}
+
+// LoadDir loads all config files of the directory dir in lexical order of their paths relative to dir, e.g. the
+// drop-in fragments of a conf.d directory. Every file is parsed with its own format and merged into the receiver,
+// so later files override earlier ones. Afterwards the config is enriched with the value from env variables
+// and the receiver is validated, see Validate.
+// Hidden files and files whose extension does not belong to an accepted format are skipped.
+// It returns the files that have been loaded in the order they have been merged.
+// @dir: The directory to load the config files from.
+// @receiver: The receiver to parse the config files into.
+func (l *Loader) LoadDir(dir string, receiver interface{}) ([]string, error) {
+	files, err := l.dirFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return files, l.LoadFiles(receiver, files...)
+}
+
+// dirFiles returns the config files of the directory dir that are loaded by LoadDir in lexical order.
+// @dir: The directory to list.
+func (l *Loader) dirFiles(dir string) ([]string, error) {
+	if l.dirPattern != "" {
+		// report malformed patterns even if the directory is empty
+		_, err := filepath.Match(l.dirPattern, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	rels := []string{}
+	err := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == dir {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if !l.dirRecursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			// symlinks are followed, e.g. the files of a mounted Kubernetes ConfigMap
+			stat, err := os.Stat(filePath)
+			if err != nil || !stat.Mode().IsRegular() {
+				return nil
+			}
+		} else if !d.Type().IsRegular() {
+			return nil
+		}
+		if !l.supportsFormat(detectFormat(filePath)) {
+			return nil
+		}
+		if l.dirPattern != "" {
+			ok, _ := filepath.Match(l.dirPattern, d.Name())
+			if !ok {
+				return nil
+			}
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(rels)
+	files := make([]string, 0, len(rels))
+	for _, rel := range rels {
+		files = append(files, filepath.Join(dir, filepath.FromSlash(rel)))
+	}
+	return files, nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoader_LoadDir(t *testing.T) {
+	type example struct {
+		Name  string
+		Port  int
+		Hosts []string
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"10-base.yaml":        "name: base\nport: 80\nhosts:\n  - a\n",
+		"20-port.json":        `{"port": 8080}`,
+		"30-hosts.toml":       "hosts = [\"b\"]\n",
+		"README.md":           "drop-in fragments",
+		".99-hidden.yaml":     "name: hidden\n",
+		"sub/40-name.yaml":    "name: sub\n",
+		"sub/50-port.yaml":    "port: 9090\n",
+		".git/60-ignored.yml": "name: ignored\n",
+	}
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(filePath), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(filePath, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		want     *example
+		wantUsed []string
+		wantErr  bool
+	}{
+		{
+			name:     "flat",
+			want:     &example{Name: "base", Port: 8080, Hosts: []string{"b"}},
+			wantUsed: []string{"10-base.yaml", "20-port.json", "30-hosts.toml"},
+		},
+		{
+			name:     "recursive",
+			opts:     []Option{WithDirRecursive(true)},
+			want:     &example{Name: "sub", Port: 9090, Hosts: []string{"b"}},
+			wantUsed: []string{"10-base.yaml", "20-port.json", "30-hosts.toml", "sub/40-name.yaml", "sub/50-port.yaml"},
+		},
+		{
+			name:     "pattern",
+			opts:     []Option{WithDirRecursive(true), WithDirPattern("*.yaml")},
+			want:     &example{Name: "sub", Port: 9090, Hosts: []string{"a"}},
+			wantUsed: []string{"10-base.yaml", "sub/40-name.yaml", "sub/50-port.yaml"},
+		},
+		{
+			name:     "append slices",
+			opts:     []Option{WithSliceMergePolicy(SliceAppend)},
+			want:     &example{Name: "base", Port: 8080, Hosts: []string{"a", "b"}},
+			wantUsed: []string{"10-base.yaml", "20-port.json", "30-hosts.toml"},
+		},
+		{
+			name:    "invalid pattern",
+			opts:    []Option{WithDirPattern("[")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &example{}
+			l := NewLoader(append([]Option{WithEnviron(func() []string { return nil })}, tt.opts...)...)
+			used, err := l.LoadDir(dir, got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Loader.LoadDir() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			wantUsed := []string{}
+			for _, name := range tt.wantUsed {
+				wantUsed = append(wantUsed, filepath.Join(dir, filepath.FromSlash(name)))
+			}
+			if diff := cmp.Diff(wantUsed, used); diff != "" {
+				t.Errorf("Loader.LoadDir() used mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.LoadDir() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadDirMissing(t *testing.T) {
+	_, err := NewLoader().LoadDir(filepath.Join(t.TempDir(), "missing"), &struct{}{})
+	if err == nil {
+		t.Errorf("Loader.LoadDir() expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// DotenvMode defines the precedence of the variables of a dotenv file.
+type DotenvMode int
+
+const (
+	// DotenvBeneath layers the dotenv file beneath the process environment,
+	// so variables that are already set by the process environment or an earlier dotenv file take precedence.
+	DotenvBeneath DotenvMode = iota
+	// DotenvAbove layers the dotenv file above the process environment,
+	// so variables of the dotenv file take precedence.
+	DotenvAbove
+)
+
+// dotenvFile is a dotenv file used as additional source of env variables.
+type dotenvFile struct {
+	filePath string
+	mode     DotenvMode
+}
+
+// WithDotenv adds the variables of the dotenv file as source of env variables, see ParseDotenv for the syntax.
+// The file is read on every load and skipped if it does not exist. Multiple files are layered in the given order.
+// @filePath: The path to the dotenv file, e.g. ".env".
+// @mode: The precedence of the variables of the file.
+func WithDotenv(filePath string, mode DotenvMode) Option {
+	return func(l *Loader) {
+		l.dotenvs = append(l.dotenvs, dotenvFile{filePath: filePath, mode: mode})
+	}
+}
+
+// ParseDotenv parses the variables of a dotenv file. Variables are expanded with the variables defined before
+// in the file and the process environment.
+//
+// Supported syntax:
+// - KEY=value, optionally prefixed with export.
+// - Comments start with # at the beginning of a line or after whitespace in unquoted values.
+// - Single quoted values are used as is and may span multiple lines.
+// - Double quoted values may span multiple lines and support the escapes \n, \r, \t, \", \\ and \$.
+// - Unquoted and double quoted values expand ${VAR}, ${VAR:-default} and ${VAR:?message}, see WithInterpolation.
+// @r: The reader to read the dotenv file from.
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	bts, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseDotenv(string(bts), os.LookupEnv)
+}
+
+// parseDotenv parses the variables of the dotenv content, see ParseDotenv.
+// @content: The content of the dotenv file.
+// @lookup: The function that returns the value of variables that are not defined in the file.
+func parseDotenv(content string, lookup func(name string) (string, bool)) (map[string]string, error) {
+	vars := map[string]string{}
+	lookupVar := func(name string) (string, bool) {
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+		return lookup(name)
+	}
+	p := &dotenvParser{s: content}
+	for {
+		p.skip(" \t\r\n")
+		if p.eof() {
+			return vars, nil
+		}
+		if p.s[p.pos] == '#' {
+			p.skipLine()
+			continue
+		}
+		line := lineOf(p.s, p.pos)
+		key, value, err := p.entry(lookupVar)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		vars[key] = value
+	}
+}
+
+// dotenvParser reads the entries of a dotenv file.
+type dotenvParser struct {
+	s   string
+	pos int
+}
+
+// eof reports whether the whole content has been read.
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+// skip advances over all characters contained in chars.
+func (p *dotenvParser) skip(chars string) {
+	for !p.eof() && strings.IndexByte(chars, p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// skipLine advances to the beginning of the next line.
+func (p *dotenvParser) skipLine() {
+	end := strings.IndexByte(p.s[p.pos:], '\n')
+	if end < 0 {
+		p.pos = len(p.s)
+		return
+	}
+	p.pos += end + 1
+}
+
+// entry reads a single KEY=value entry.
+func (p *dotenvParser) entry(lookup func(name string) (string, bool)) (string, string, error) {
+	if strings.HasPrefix(p.s[p.pos:], "export ") || strings.HasPrefix(p.s[p.pos:], "export\t") {
+		p.pos += len("export")
+		p.skip(" \t")
+	}
+	start := p.pos
+	for !p.eof() && (isNameChar(p.s[p.pos], p.pos == start) || p.pos > start && p.s[p.pos] == '.') {
+		p.pos++
+	}
+	key := p.s[start:p.pos]
+	if key == "" {
+		return "", "", fmt.Errorf("invalid variable name")
+	}
+	p.skip(" \t")
+	if p.eof() || p.s[p.pos] != '=' {
+		return "", "", fmt.Errorf("missing = after %s", key)
+	}
+	p.pos++
+	p.skip(" \t")
+	value, err := p.value(lookup)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", key, err)
+	}
+	return key, value, nil
+}
+
+// value reads the value of an entry.
+func (p *dotenvParser) value(lookup func(name string) (string, bool)) (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+	switch p.s[p.pos] {
+	case '\'':
+		end := strings.IndexByte(p.s[p.pos+1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quoted value")
+		}
+		value := p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value, p.endOfValue()
+	case '"':
+		b := strings.Builder{}
+		i := p.pos + 1
+		for ; i < len(p.s) && p.s[i] != '"'; i++ {
+			if p.s[i] != '\\' || i+1 == len(p.s) {
+				b.WriteByte(p.s[i])
+				continue
+			}
+			i++
+			switch p.s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '$':
+				// escaped for the expansion
+				b.WriteString("$$")
+			case '"', '\\':
+				b.WriteByte(p.s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(p.s[i])
+			}
+		}
+		if i >= len(p.s) {
+			return "", fmt.Errorf("unterminated double quoted value")
+		}
+		p.pos = i + 1
+		err := p.endOfValue()
+		if err != nil {
+			return "", err
+		}
+		value, err := substitute(b.String(), lookup)
+		return value, err
+	default:
+		end := strings.IndexByte(p.s[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.s) - p.pos
+		}
+		raw := p.s[p.pos : p.pos+end]
+		p.pos += end
+		for i := 0; i < len(raw); i++ {
+			// a leading # starts a comment as well, e.g. A= # comment
+			if raw[i] == '#' && (i == 0 || raw[i-1] == ' ' || raw[i-1] == '\t') {
+				raw = raw[:i]
+				break
+			}
+		}
+		value, err := substitute(strings.TrimSpace(raw), lookup)
+		return value, err
+	}
+}
+
+// endOfValue checks that only whitespace or a comment follows a quoted value.
+func (p *dotenvParser) endOfValue() error {
+	p.skip(" \t\r")
+	if p.eof() || p.s[p.pos] == '\n' {
+		return nil
+	}
+	if p.s[p.pos] == '#' {
+		p.skipLine()
+		return nil
+	}
+	return fmt.Errorf("unexpected %q after quoted value", p.s[p.pos])
+}
+
+// applyDotenvs layers the dotenv files of the Loader onto vars.
+// @vars: The variables of the process environment.
+func (l *Loader) applyDotenvs(vars map[string]string) error {
+	for _, dotenv := range l.dotenvs {
+		bts, err := ioutil.ReadFile(dotenv.filePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fileVars, err := parseDotenv(string(bts), func(name string) (string, bool) {
+			value, ok := vars[name]
+			return value, ok
+		})
+		if err != nil {
+			return fmt.Errorf("failed to parse dotenv file %q: %w", dotenv.filePath, err)
+		}
+		for name, value := range fileVars {
+			if _, ok := vars[name]; ok && dotenv.mode == DotenvBeneath {
+				continue
+			}
+			vars[name] = value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseDotenv(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "HOME" {
+			return "/home/sam", true
+		}
+		return "", false
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "unquoted",
+			content: "NAME=Simple Sam\nPORT = 8080\n\nEMPTY=\n",
+			want:    map[string]string{"NAME": "Simple Sam", "PORT": "8080", "EMPTY": ""},
+		},
+		{
+			name:    "export and comments",
+			content: "# comment\n  # indented comment\nexport NAME=sam # trailing comment\nURL=http://host/#anchor\n",
+			want:    map[string]string{"NAME": "sam", "URL": "http://host/#anchor"},
+		},
+		{
+			name:    "empty values with comments",
+			content: "A= # comment\nB=# comment\nC=#\n",
+			want:    map[string]string{"A": "", "B": "", "C": ""},
+		},
+		{
+			name:    "single quoted",
+			content: "PASS='p@ss ${HOME} \\n # no comment' # comment\n",
+			want:    map[string]string{"PASS": "p@ss ${HOME} \\n # no comment"},
+		},
+		{
+			name:    "double quoted escapes",
+			content: `MSG="line1\nline2\t\"quoted\" \\ \$HOME \${HOME}"`,
+			want:    map[string]string{"MSG": "line1\nline2\t\"quoted\" \\ $HOME ${HOME}"},
+		},
+		{
+			name:    "multiline",
+			content: "CERT=\"-----BEGIN-----\nabc\n-----END-----\"\nKEY='a\nb'\nNEXT=1\n",
+			want:    map[string]string{"CERT": "-----BEGIN-----\nabc\n-----END-----", "KEY": "a\nb", "NEXT": "1"},
+		},
+		{
+			name:    "expansion",
+			content: "DIR=${HOME}/app\nDATA=\"${DIR}/data\"\nHOST=${DB_HOST:-localhost}\n",
+			want:    map[string]string{"DIR": "/home/sam/app", "DATA": "/home/sam/app/data", "HOST": "localhost"},
+		},
+		{
+			name:    "windows line endings",
+			content: "NAME=sam\r\nPORT=\"8080\"\r\n",
+			want:    map[string]string{"NAME": "sam", "PORT": "8080"},
+		},
+		{
+			name:    "missing equal sign",
+			content: "NAME=sam\nINVALID\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			content: "1NAME=sam\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated double quote",
+			content: "NAME=\"sam\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated single quote",
+			content: "NAME='sam\n",
+			wantErr: true,
+		},
+		{
+			name:    "characters after quoted value",
+			content: "NAME=\"sam\" sam\n",
+			wantErr: true,
+		},
+		{
+			name:    "required variable",
+			content: "HOST=${DB_HOST:?must be set}\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDotenv(tt.content, lookup)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDotenv() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseDotenv() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	got, err := ParseDotenv(strings.NewReader("export NAME='Simple Sam'\n"))
+	if err != nil {
+		t.Fatalf("ParseDotenv() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]string{"NAME": "Simple Sam"}, got); diff != "" {
+		t.Errorf("ParseDotenv() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoader_LoadDotenv(t *testing.T) {
+	type example struct {
+		Name string
+		Age  int
+		Host string
+	}
+	dir := t.TempDir()
+	dotenv := filepath.Join(dir, ".env")
+	err := ioutil.WriteFile(dotenv, []byte("CFG_NAME=dotenv\nCFG_AGE=40\nCFG_HOST=${HOST:-localhost}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.env")
+	err = ioutil.WriteFile(invalid, []byte("CFG_NAME=\"dotenv\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	environ := func() []string { return []string{"CFG_NAME=process", "HOST=db"} }
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    *example
+		wantErr bool
+	}{
+		{
+			name: "beneath process env",
+			opts: []Option{WithDotenv(dotenv, DotenvBeneath)},
+			want: &example{Name: "process", Age: 40, Host: "db"},
+		},
+		{
+			name: "above process env",
+			opts: []Option{WithDotenv(dotenv, DotenvAbove)},
+			want: &example{Name: "dotenv", Age: 40, Host: "db"},
+		},
+		{
+			name: "missing file",
+			opts: []Option{WithDotenv(filepath.Join(dir, "missing.env"), DotenvAbove)},
+			want: &example{Name: "process", Age: 25},
+		},
+		{
+			name:    "invalid file",
+			opts:    []Option{WithDotenv(invalid, DotenvAbove)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &example{}
+			l := NewLoader(append([]Option{WithEnviron(environ)}, tt.opts...)...)
+			err := l.Load(".file/simple.yml", got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Loader.Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.Load() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
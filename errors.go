@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// EnvParseError describes an env variable whose value could not be parsed into the type of the target field.
+type EnvParseError struct {
+	// Name is the name of the env variable.
+	Name string
+	// Field is the path of the target field, e.g. Server.Port.
+	Field string
+	// Value is the raw value of the env variable. It is RedactedValue for fields tagged with `secret:"true"`.
+	Value string
+	// Err is the underlying parse error.
+	Err error
+}
+
+func (e *EnvParseError) Error() string {
+	return fmt.Sprintf("env %s: field %s: invalid value %q: %v", e.Name, e.Field, e.Value, e.Err)
+}
+
+func (e *EnvParseError) Unwrap() error {
+	return e.Err
+}
+
+// secretValueError hides the message of an error that may contain a secret value or the content of a secret file.
+// The original error is still available via errors.Is and errors.As.
+type secretValueError struct {
+	typ  reflect.Type
+	err  error
+	file bool
+}
+
+func (e *secretValueError) Error() string {
+	if e.file {
+		return fmt.Sprintf("the content of the file is not a valid %s", e.typ)
+	}
+	return fmt.Sprintf("the secret value is not a valid %s", e.typ)
+}
+
+func (e *secretValueError) Unwrap() error {
+	return e.err
+}
+
+// EnvErrors is returned if one or more env variables could not be parsed.
+type EnvErrors []*EnvParseError
+
+func (e EnvErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("failed to parse %d env variable(s): %s", len(e), strings.Join(msgs, "; "))
+}
+
+// FieldError describes a field that failed a validation rule.
+type FieldError struct {
+	// Field is the path of the field, e.g. Server.Port. It is empty if the receiver itself failed its Validator.
+	Field string
+	// Rule is the name of the failed rule, e.g. required or validator for custom validations.
+	Rule string
+	// Message describes why the rule failed.
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors is returned if one or more fields failed validation.
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("validation failed for %d field(s): %s", len(e), strings.Join(msgs, "; "))
+}
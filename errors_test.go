@@ -0,0 +1,38 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestEnvErrors_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		e    EnvErrors
+		want string
+	}{
+		{
+			name: "single error",
+			e: EnvErrors{
+				{Name: "CFG_PORT", Field: "Port", Value: "abc", Err: strconv.ErrSyntax},
+			},
+			want: `failed to parse 1 env variable(s): env CFG_PORT: field Port: invalid value "abc": invalid syntax`,
+		},
+		{
+			name: "multiple errors",
+			e: EnvErrors{
+				{Name: "CFG_PORT", Field: "Port", Value: "abc", Err: strconv.ErrSyntax},
+				{Name: "CFG_SERVER_ENABLED", Field: "Server.Enabled", Value: "yes", Err: errors.New("boom")},
+			},
+			want: `failed to parse 2 env variable(s): env CFG_PORT: field Port: invalid value "abc": invalid syntax; env CFG_SERVER_ENABLED: field Server.Enabled: invalid value "yes": boom`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Error(); got != tt.want {
+				t.Errorf("EnvErrors.Error() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	stringType    = reflect.TypeOf("")
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// Marshal encodes the receiver in the format f, e.g. to dump the effective config after it has been loaded.
+// The keys follow the tags of the format the same way as decoding, so the result can be loaded again.
+// Time types are encoded with the syntax that is accepted when loading, i.e. durations like 1m30s,
+// times in the first time layout of the Loader and locations by their name.
+// For HCL, nested structs and slices of structs are encoded as blocks, the block option of all other fields
+// is ignored. HCL cannot represent maps of structs, encoding them returns an error.
+// @receiver: The config to encode, a struct or a pointer to a struct.
+// @f: The format to encode the config in. If it is empty, the format forced by WithFormat is used.
+func (l *Loader) Marshal(receiver interface{}, f Format) ([]byte, error) {
+	return l.marshal(receiver, f, false)
+}
+
+// WriteFile encodes the receiver and writes it to the config file, see Marshal.
+// The format is detected by the file extension, unless it is forced by WithFormat.
+// @filePath: The path to the config file.
+// @receiver: The config to encode, a struct or a pointer to a struct.
+func (l *Loader) WriteFile(filePath string, receiver interface{}) error {
+	f := l.format
+	if f == "" {
+		f = detectFormat(filePath)
+	}
+	bts, err := l.Marshal(receiver, f)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, bts, 0644)
+}
+
+// marshal encodes the receiver in the format f, see Marshal.
+// @redact: Whether the values of secret fields are masked.
+func (l *Loader) marshal(receiver interface{}, f Format, redact bool) ([]byte, error) {
+	if f == "" {
+		f = l.format
+	}
+	if !l.supportsFormat(f) {
+		return nil, fmt.Errorf("unsupported format: %s", f)
+	}
+	view, err := l.exportView(receiver, f, redact)
+	if err != nil {
+		return nil, err
+	}
+	if f == HCL {
+		err = checkHCLType(reflect.TypeOf(view), map[reflect.Type]bool{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return marshal(view, f)
+}
+
+// exportView converts the receiver into the value that is encoded, see exporter.
+// @receiver: The config to convert.
+// @f: The format the view is encoded in, empty if the view may be passed to any encoder.
+// @redact: Whether the values of secret fields are masked.
+func (l *Loader) exportView(receiver interface{}, f Format, redact bool) (interface{}, error) {
+	val := reflect.ValueOf(receiver)
+	if !val.IsValid() {
+		return nil, fmt.Errorf("receiver must not be nil")
+	}
+	e := &exporter{Loader: l, format: f, redact: redact, visiting: map[reflect.Type]bool{}}
+	return e.exportValue(val, e.exportType(val.Type())).Interface(), nil
+}
+
+// exporter converts configs into the values that are encoded.
+// Time types are replaced by strings, since the encoders do not use the syntax that is accepted when loading,
+// e.g. encoding/json encodes durations as nanoseconds. This includes the elements of slices, arrays and maps,
+// which are extracted by extractTimeValues as well.
+// If redact is true, the values of secret fields are masked, see Redact.
+// For HCL, the hcl tags are adjusted, so that structs are encoded as blocks, see hclExportTag.
+// Recursive types are replaced by interface{} and converted while the value is exported.
+// The fields of embedded structs are promoted into the converted structs like encoding/json does, see exportFields.
+type exporter struct {
+	*Loader
+	format Format
+	redact bool
+	// visiting contains the struct types that are currently visited, used to detect recursive types.
+	visiting map[reflect.Type]bool
+}
+
+// exportType returns the type that is encoded for values of type t.
+// Types that neither contain time types nor secret fields are returned as is.
+// @t: The type to return the encoded type for.
+func (e *exporter) exportType(t reflect.Type) reflect.Type {
+	if isTimeType(t) {
+		return stringType
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := e.exportType(t.Elem())
+		if elem == interfaceType {
+			return interfaceType
+		}
+		if elem != t.Elem() {
+			return reflect.PtrTo(elem)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if isScalarType(t) {
+			return t
+		}
+		elem := e.exportType(t.Elem())
+		switch {
+		case elem == t.Elem():
+			return t
+		case t.Kind() == reflect.Slice:
+			return reflect.SliceOf(elem)
+		case t.Kind() == reflect.Array:
+			return reflect.ArrayOf(t.Len(), elem)
+		default:
+			return reflect.MapOf(t.Key(), elem)
+		}
+	case reflect.Struct:
+		if e.visiting[t] {
+			return interfaceType
+		}
+		e.visiting[t] = true
+		defer delete(e.visiting, t)
+		fields := make([]reflect.StructField, 0, t.NumField())
+		changed := false
+		for _, ef := range exportFields(t) {
+			var ft reflect.Type
+			if e.redact && isSecret(ef.field) {
+				ft = secretType(ef.field.Type)
+			} else {
+				ft = e.exportType(ef.field.Type)
+			}
+			tag := ef.field.Tag
+			if e.format == HCL {
+				tag = hclExportTag(ef.field, ft)
+			}
+			if ft != ef.field.Type || tag != ef.field.Tag {
+				changed = true
+			}
+			fields = append(fields, reflect.StructField{Name: ef.field.Name, Type: ft, Tag: tag})
+		}
+		if changed {
+			return reflect.StructOf(fields)
+		}
+	}
+	return t
+}
+
+// exportValue converts v to the type t returned by exportType.
+// @v: The value to convert.
+// @t: The encoded type of v.
+func (e *exporter) exportValue(v reflect.Value, t reflect.Type) reflect.Value {
+	if v.Type() == t {
+		return v
+	}
+	out := reflect.New(t).Elem()
+	switch {
+	case t == interfaceType:
+		out.Set(e.exportValue(v, e.exportType(v.Type())))
+	case isTimeType(v.Type()):
+		out.SetString(e.timeString(v))
+	case v.Kind() == reflect.Ptr:
+		if !v.IsNil() {
+			elem := reflect.New(t.Elem())
+			elem.Elem().Set(e.exportValue(v.Elem(), t.Elem()))
+			out.Set(elem)
+		}
+	case v.Kind() == reflect.Slice:
+		if !v.IsNil() {
+			out.Set(reflect.MakeSlice(t, v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				out.Index(i).Set(e.exportValue(v.Index(i), t.Elem()))
+			}
+		}
+	case v.Kind() == reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(e.exportValue(v.Index(i), t.Elem()))
+		}
+	case v.Kind() == reflect.Map:
+		if !v.IsNil() {
+			out.Set(reflect.MakeMapWithSize(t, v.Len()))
+			iter := v.MapRange()
+			for iter.Next() {
+				out.SetMapIndex(iter.Key(), e.exportValue(iter.Value(), t.Elem()))
+			}
+		}
+	case v.Kind() == reflect.Struct:
+		for j, ef := range exportFields(v.Type()) {
+			fv := fieldByIndex(v, ef.index)
+			if e.redact && isSecret(ef.field) {
+				out.Field(j).Set(secretValue(fv, t.Field(j).Type))
+			} else {
+				out.Field(j).Set(e.exportValue(fv, t.Field(j).Type))
+			}
+		}
+	}
+	return out
+}
+
+// timeString returns the string representation of the time type v that is accepted by setTimeFromString.
+// @v: The value to format. Its type must satisfy isTimeType.
+func (l *Loader) timeString(v reflect.Value) string {
+	switch t := v.Interface().(type) {
+	case time.Duration:
+		return t.String()
+	case time.Time:
+		layout := time.RFC3339Nano
+		if len(l.timeLayouts) > 0 {
+			layout = l.timeLayouts[0]
+		}
+		return t.Format(layout)
+	case time.Location:
+		return t.String()
+	}
+	return ""
+}
+
+// exportField is a field of a struct type that is encoded, see exportFields.
+type exportField struct {
+	field reflect.StructField
+	// index is the index sequence of the field within the struct, see reflect.Value.FieldByIndex.
+	index []int
+}
+
+// exportFields returns the exported fields of the struct type t in the order of their declaration.
+// The fields of embedded structs are returned in place of the embedded field, since reflect.StructOf does not promote
+// them. A promoted field is left out if t already declares a field with the same name, i.e. the shallower field wins.
+// @t: The struct type to return the fields of.
+func exportFields(t reflect.Type) []exportField {
+	declared := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).Anonymous {
+			declared[t.Field(i).Name] = true
+		}
+	}
+	return appendExportFields(nil, t, nil, declared, map[reflect.Type]bool{t: true})
+}
+
+// appendExportFields is the recursive part of exportFields.
+// @fields: The fields to append to.
+// @t: The struct type to append the fields of.
+// @index: The index sequence of t within the outermost struct.
+// @declared: The names of the fields that have already been taken.
+// @visited: The struct types that are currently visited, used to skip recursive embedding.
+func appendExportFields(fields []exportField, t reflect.Type, index []int, declared map[string]bool, visited map[reflect.Type]bool) []exportField {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+		embedded := field.Type
+		if embedded.Kind() == reflect.Ptr {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && embedded.Kind() == reflect.Struct && !isScalarType(embedded) {
+			if !visited[embedded] {
+				visited[embedded] = true
+				fields = appendExportFields(fields, embedded, fieldIndex, declared, visited)
+				delete(visited, embedded)
+			}
+			continue
+		}
+		if field.PkgPath != "" || (len(index) > 0 && declared[field.Name]) {
+			// unexported fields are not encoded
+			continue
+		}
+		if len(index) > 0 {
+			declared[field.Name] = true
+		}
+		field.Anonymous = false
+		fields = append(fields, exportField{field: field, index: fieldIndex})
+	}
+	return fields
+}
+
+// fieldByIndex returns the nested field of the struct v like reflect.Value.FieldByIndex,
+// but returns the zero value of the field if it is promoted through a nil pointer.
+// @v: The struct value.
+// @index: The index sequence of the field.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(v.Type().Elem().FieldByIndex(index[i:]).Type)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// hclExportTag returns the tag of the field with an hcl tag that can be encoded by hcl.Marshal.
+// Fields of type t that hold structs or slices of structs are tagged as block, since hcl.Marshal only encodes
+// structs as blocks, while the block option is removed from all other fields. Labels are kept as they are.
+// @field: The field to return the tag for.
+// @t: The encoded type of the field, see exportType.
+func hclExportTag(field reflect.StructField, t reflect.Type) reflect.StructTag {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	isBlock := t.Kind() == reflect.Struct && !isScalarType(t)
+	tag, ok := field.Tag.Lookup("hcl")
+	if !ok {
+		// hcl.Marshal falls back to the json tag
+		tag = field.Tag.Get("json")
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "-" {
+		return field.Tag
+	}
+	if name == "" {
+		name = field.Name
+	}
+	option := ""
+	if len(parts) > 1 {
+		option = parts[1]
+	}
+	switch {
+	case option == "label" || option == "remain":
+		return field.Tag
+	case isBlock && option != "block":
+		return setTag(field.Tag, "hcl", name+",block")
+	case !isBlock && option == "block":
+		return setTag(field.Tag, "hcl", name+",optional")
+	default:
+		return field.Tag
+	}
+}
+
+// checkHCLType returns an error if values of type t cannot be encoded by hcl.Marshal, i.e. if t contains maps of structs.
+// @t: The type to check.
+// @visited: The struct types that have already been checked.
+func checkHCLType(t reflect.Type, visited map[reflect.Type]bool) error {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return checkHCLType(t.Elem(), visited)
+	case reflect.Map:
+		elem := t.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Struct && !isScalarType(elem) {
+			return fmt.Errorf("hcl does not support maps of structs, got %s", t)
+		}
+		return checkHCLType(elem, visited)
+	case reflect.Struct:
+		if visited[t] {
+			return nil
+		}
+		visited[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			err := checkHCLType(t.Field(i).Type, visited)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", t.Field(i).Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setTag returns tag with the value of the key replaced or added.
+func setTag(tag reflect.StructTag, key, value string) reflect.StructTag {
+	entry := key + ":" + strconv.Quote(value)
+	if old, ok := tag.Lookup(key); ok {
+		return reflect.StructTag(strings.Replace(string(tag), key+":"+strconv.Quote(old), entry, 1))
+	}
+	if tag == "" {
+		return reflect.StructTag(entry)
+	}
+	return reflect.StructTag(string(tag) + " " + entry)
+}
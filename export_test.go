@@ -0,0 +1,230 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type exportServer struct {
+	Host        string
+	Port        *int
+	ReadTimeout time.Duration
+}
+
+type exportExample struct {
+	Name      string
+	Age       int
+	Size      float64
+	IsActive  bool
+	Hosts     []string
+	Labels    map[string]string
+	Timeout   time.Duration
+	Started   time.Time
+	Retries   []time.Duration          `hcl:"retries,optional"`
+	Deadlines map[string]time.Duration `hcl:"deadlines,optional"`
+	Server    exportServer             `hcl:"server,block"`
+	Backends  []exportServer           `hcl:"backends,block"`
+}
+
+func newExportExample() *exportExample {
+	port := 8080
+	return &exportExample{
+		Name:     "Simple Sam",
+		Age:      25,
+		Size:     1.87,
+		IsActive: true,
+		Hosts:    []string{"localhost", "127.0.0.1"},
+		Labels:   map[string]string{"team": "core"},
+		Timeout:  90 * time.Second,
+		Started:  time.Date(2022, 1, 2, 15, 4, 5, 123, time.UTC),
+		Retries:  []time.Duration{time.Second, 5 * time.Second},
+		Deadlines: map[string]time.Duration{
+			"read": 30 * time.Second,
+		},
+		Server:   exportServer{Host: "0.0.0.0", Port: &port, ReadTimeout: 5 * time.Second},
+		Backends: []exportServer{{Host: "a", ReadTimeout: time.Minute}, {Host: "b"}},
+	}
+}
+
+func TestLoader_MarshalRoundTrip(t *testing.T) {
+	for _, f := range []Format{YAML, JSON, TOML, HCL} {
+		t.Run(string(f), func(t *testing.T) {
+			l := NewLoader(WithEnviron(func() []string { return nil }))
+			want := newExportExample()
+			bts, err := l.Marshal(want, f)
+			if err != nil {
+				t.Fatalf("Loader.Marshal() error = %v", err)
+			}
+			got := &exportExample{}
+			err = l.LoadBytes(bts, f, got)
+			if err != nil {
+				t.Fatalf("Loader.LoadBytes() error = %v\n%s", err, bts)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Loader.Marshal() round trip mismatch (-want +got):\n%s\n%s", diff, bts)
+			}
+		})
+	}
+}
+
+func TestLoader_MarshalRoundTripFiles(t *testing.T) {
+	for _, filePath := range []string{".file/simple.yml", ".file/simple.json", ".file/simple.toml", ".file/simple.hcl"} {
+		t.Run(filePath, func(t *testing.T) {
+			l := NewLoader(WithEnviron(func() []string { return nil }))
+			want := &ExampleConfigA{}
+			err := l.Load(filePath, want)
+			if err != nil {
+				t.Fatalf("Loader.Load() error = %v", err)
+			}
+			f := detectFormat(filePath)
+			bts, err := l.Marshal(want, f)
+			if err != nil {
+				t.Fatalf("Loader.Marshal() error = %v", err)
+			}
+			got := &ExampleConfigA{}
+			err = l.LoadBytes(bts, f, got)
+			if err != nil {
+				t.Fatalf("Loader.LoadBytes() error = %v\n%s", err, bts)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Loader.Marshal() round trip mismatch (-want +got):\n%s\n%s", diff, bts)
+			}
+		})
+	}
+}
+
+func TestLoader_Marshal(t *testing.T) {
+	type example struct {
+		Name     string `yaml:"full_name"`
+		Timeout  time.Duration
+		Birthday time.Time
+		Location time.Location
+		Parent   *example
+		internal string
+	}
+	tests := []struct {
+		name     string
+		receiver interface{}
+		f        Format
+		opts     []Option
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "yaml",
+			receiver: example{
+				Name:     "Sam",
+				Timeout:  90 * time.Second,
+				Birthday: time.Date(1990, 12, 24, 0, 0, 0, 0, time.UTC),
+				Location: *time.UTC,
+				Parent:   &example{Name: "Chris", Location: *time.UTC},
+				internal: "hidden",
+			},
+			f:    YAML,
+			opts: []Option{WithTimeLayouts("2006-01-02")},
+			want: "full_name: Sam\ntimeout: 1m30s\nbirthday: \"1990-12-24\"\nlocation: UTC\nparent:\n    full_name: Chris\n    timeout: 0s\n    birthday: \"0001-01-01\"\n    location: UTC\n    parent: null\n",
+		},
+		{
+			name: "json time types within slices and maps",
+			receiver: &struct {
+				Retries   []time.Duration
+				Deadlines map[string]*time.Duration
+			}{
+				Retries:   []time.Duration{time.Second},
+				Deadlines: map[string]*time.Duration{"read": nil},
+			},
+			f:    JSON,
+			want: "{\n    \"Retries\": [\n        \"1s\"\n    ],\n    \"Deadlines\": {\n        \"read\": null\n    }\n}",
+		},
+		{
+			name:     "forced format",
+			receiver: &struct{ Name string }{Name: "Sam"},
+			opts:     []Option{WithFormat(JSON)},
+			want:     "{\n    \"Name\": \"Sam\"\n}",
+		},
+		{
+			name:     "restricted format",
+			receiver: &struct{ Name string }{Name: "Sam"},
+			f:        TOML,
+			opts:     []Option{WithFormats(YAML)},
+			wantErr:  true,
+		},
+		{
+			name:     "no format",
+			receiver: &struct{ Name string }{Name: "Sam"},
+			wantErr:  true,
+		},
+		{
+			name:    "nil receiver",
+			f:       YAML,
+			wantErr: true,
+		},
+		{
+			name:     "hcl nested struct without block tag",
+			receiver: &struct{ Server struct{ Port int } }{Server: struct{ Port int }{Port: 80}},
+			f:        HCL,
+			want:     "Server {\n  Port = 80\n}\n",
+		},
+		{
+			name: "hcl block tag on attribute",
+			receiver: &struct {
+				Hosts []string `hcl:"hosts,block"`
+			}{Hosts: []string{"a"}},
+			f:    HCL,
+			want: "hosts = [\"a\"]\n",
+		},
+		{
+			name:     "hcl map of structs",
+			receiver: &struct{ Servers map[string]struct{ Port int } }{},
+			f:        HCL,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLoader(tt.opts...).Marshal(tt.receiver, tt.f)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Loader.Marshal() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("Loader.Marshal() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_WriteFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"config.yml", "config.json", "config.toml", "config.hcl"} {
+		t.Run(name, func(t *testing.T) {
+			filePath := filepath.Join(dir, name)
+			l := NewLoader(WithEnviron(func() []string { return []string{"CFG_AGE=30"} }))
+			want := newExportExample()
+			err := l.Load(".file/simple.yml", want)
+			if err != nil {
+				t.Fatalf("Loader.Load() error = %v", err)
+			}
+			err = l.WriteFile(filePath, want)
+			if err != nil {
+				t.Fatalf("Loader.WriteFile() error = %v", err)
+			}
+			got := &exportExample{}
+			err = AutoloadAndEnrichConfig(filePath, got)
+			if err != nil {
+				t.Fatalf("AutoloadAndEnrichConfig() error = %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Loader.WriteFile() round trip mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	err := NewLoader().WriteFile(filepath.Join(dir, "config.ini"), newExportExample())
+	if err == nil {
+		t.Errorf("Loader.WriteFile() expected error for unknown extension")
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// descTag is the struct tag used to define the usage text of the command-line flag of a field.
+const descTag = "desc"
+
+// flagDelimiter separates the parts of a flag name, e.g. --server-port.
+const flagDelimiter = "-"
+
+// WithFlagSet defines the flag set that RegisterFlags registers the flags on.
+// The flags that have been set on the command line are applied as the layer with the highest precedence,
+// after the config files and the env variables and before the receiver is validated.
+func WithFlagSet(fs *flag.FlagSet) Option {
+	return func(l *Loader) {
+		l.flagSet = fs
+	}
+}
+
+// RegisterFlags walks through the type of the receiver the same way as the env enrichment and registers a flag
+// for every field on the flag set of the Loader, see WithFlagSet. The name of the flag is the lower cased path
+// of the field separated by dashes, e.g. --server-port for Server.Port, and follows the env tag.
+// The usage text is taken from the desc tag, the default value from the default tag.
+// Slices may be set multiple times to append elements, e.g. --hosts a --hosts b, maps to add entries.
+// Fields of other slices and maps, e.g. slices of structs, are skipped.
+// RegisterFlags must be called before the flag set is parsed.
+// @receiver: The pointer to the struct to register the flags for.
+func (l *Loader) RegisterFlags(receiver interface{}) error {
+	if l.flagSet == nil {
+		return fmt.Errorf("no flag set defined, use WithFlagSet")
+	}
+	t := reflect.TypeOf(receiver)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("receiver must be a pointer to a struct, got: %T", receiver)
+	}
+	return l.registerStructFlags(t.Elem(), t.Elem(), "", "", nil)
+}
+
+// registerStructFlags is the recursive part of RegisterFlags.
+// @root: The type of the receiver.
+// @t: The struct type to register the flags for.
+// @prefix: The flag name of the parent.
+// @fieldPath: The path of t within the receiver.
+// @index: The field index of t within the receiver.
+func (l *Loader) registerStructFlags(root, t reflect.Type, prefix, fieldPath string, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		name, noPrefix, skip := envTagName(field)
+		if skip {
+			continue
+		}
+		name = strings.ToLower(name)
+		if prefix != "" && !noPrefix {
+			name = prefix + flagDelimiter + name
+		}
+		path := joinFieldPath(fieldPath, field.Name)
+		fieldIndex := append(append([]int{}, index...), i)
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !isScalarType(ft) {
+			err := l.registerStructFlags(root, ft, name, path, fieldIndex)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !isFlagType(ft) {
+			continue
+		}
+		if l.flagSet.Lookup(name) != nil {
+			return fmt.Errorf("flag --%s of field %s is already defined", name, path)
+		}
+		l.flagSet.Var(&fieldFlag{
+			loader:    l,
+			root:      root,
+			typ:       field.Type,
+			fieldPath: path,
+			index:     fieldIndex,
+			defValue:  field.Tag.Get(defaultTag),
+		}, name, field.Tag.Get(descTag))
+	}
+	return nil
+}
+
+// isFlagType reports whether values of type t can be set by a flag.
+func isFlagType(t reflect.Type) bool {
+	switch {
+	case isElementType(t):
+		return true
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return isElementType(t.Elem())
+	case t.Kind() == reflect.Map:
+		return isElementType(t.Key()) && isElementType(t.Elem())
+	default:
+		return false
+	}
+}
+
+// applyFlags sets the fields of the receiver from the flags that have been set on the flag set of the Loader.
+// @receiver: The pointer to the struct to apply the flags to.
+func (l *Loader) applyFlags(receiver interface{}) error {
+	if l.flagSet == nil {
+		return nil
+	}
+	val := reflect.ValueOf(receiver)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	var err error
+	l.flagSet.Visit(func(fl *flag.Flag) {
+		ff, ok := fl.Value.(*fieldFlag)
+		if !ok || ff.root != val.Type() || err != nil {
+			return
+		}
+		applyErr := ff.apply(val)
+		if applyErr != nil {
+			err = fmt.Errorf("flag --%s: %w", fl.Name, applyErr)
+			return
+		}
+		l.rec.record(ff.fieldPath, Source{Kind: SourceFlag, Name: "--" + fl.Name})
+	})
+	return err
+}
+
+// fieldFlag is the flag.Value of a field registered by RegisterFlags.
+type fieldFlag struct {
+	loader *Loader
+	// root is the type of the receiver the flag has been registered for.
+	root reflect.Type
+	typ  reflect.Type
+	// fieldPath is the path of the field within root.
+	fieldPath string
+	// index is the field index within root.
+	index    []int
+	defValue string
+	// values contains the raw values in the order they have been set.
+	values []string
+}
+
+func (f *fieldFlag) String() string {
+	if f == nil || f.loader == nil {
+		return ""
+	}
+	if len(f.values) == 0 {
+		return f.defValue
+	}
+	return strings.Join(f.values, f.loader.envSliceDelimiter)
+}
+
+// Set validates the raw value against the type of the field and records it.
+func (f *fieldFlag) Set(raw string) error {
+	err := f.loader.setValueFromString(reflect.New(f.typ).Elem(), raw)
+	if err != nil {
+		return err
+	}
+	f.values = append(f.values, raw)
+	return nil
+}
+
+// IsBoolFlag allows to set boolean fields without a value, e.g. --debug.
+func (f *fieldFlag) IsBoolFlag() bool {
+	t := f.typ
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// apply sets the field of the receiver val from the recorded values.
+// Elements of slices are appended, entries of maps are merged and all other fields are set from the last value.
+// @val: The struct value of the receiver.
+func (f *fieldFlag) apply(val reflect.Value) error {
+	v := val
+	for _, i := range f.index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	switch {
+	case v.Kind() == reflect.Slice && !isScalarType(v.Type()):
+		elems := reflect.MakeSlice(v.Type(), 0, len(f.values))
+		for _, raw := range f.values {
+			part := reflect.New(v.Type()).Elem()
+			err := f.loader.setValueFromString(part, raw)
+			if err != nil {
+				return err
+			}
+			elems = reflect.AppendSlice(elems, part)
+		}
+		v.Set(elems)
+	case v.Kind() == reflect.Map:
+		for _, raw := range f.values {
+			err := f.loader.setValueFromString(v, raw)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return f.loader.setValueFromString(v, f.values[len(f.values)-1])
+	}
+	return nil
+}
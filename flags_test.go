@@ -0,0 +1,197 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type flagExample struct {
+	Name     string            `desc:"the name of the user"`
+	Age      int               `default:"18"`
+	IsActive bool              `desc:"whether the user is active"`
+	Hosts    []string          `desc:"the hosts to connect to"`
+	Labels   map[string]string `env:"TAGS"`
+	Secret   string            `env:"-"`
+	Children struct {
+		Name string
+		Port int `env:"PORT,noprefix"`
+	}
+	Server *struct {
+		Port int
+	}
+}
+
+func TestLoader_RegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	l := NewLoader(WithFlagSet(fs))
+	err := l.RegisterFlags(&flagExample{})
+	if err != nil {
+		t.Fatalf("Loader.RegisterFlags() error = %v", err)
+	}
+	names := []string{}
+	fs.VisitAll(func(fl *flag.Flag) {
+		names = append(names, fl.Name)
+	})
+	want := []string{"age", "children-name", "hosts", "isactive", "name", "port", "server-port", "tags"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("Loader.RegisterFlags() names mismatch (-want +got):\n%s", diff)
+	}
+
+	usage := &bytes.Buffer{}
+	fs.SetOutput(usage)
+	fs.PrintDefaults()
+	for _, s := range []string{"the name of the user", "(default 18)", "whether the user is active"} {
+		if !strings.Contains(usage.String(), s) {
+			t.Errorf("Loader.RegisterFlags() usage does not contain %q:\n%s", s, usage.String())
+		}
+	}
+
+	err = l.RegisterFlags(&flagExample{})
+	if err == nil {
+		t.Errorf("Loader.RegisterFlags() expected error for duplicate flags")
+	}
+	err = NewLoader().RegisterFlags(&flagExample{})
+	if err == nil {
+		t.Errorf("Loader.RegisterFlags() expected error without flag set")
+	}
+	err = l.RegisterFlags(flagExample{})
+	if err == nil {
+		t.Errorf("Loader.RegisterFlags() expected error for non pointer receiver")
+	}
+}
+
+func TestLoader_LoadFlags(t *testing.T) {
+	type example struct {
+		Name     string
+		Age      int
+		Size     float64
+		IsActive bool
+		Hosts    []string
+		Labels   map[string]string
+		Children struct {
+			Name string
+			Age  int
+		}
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		environ []string
+		want    *example
+		wantErr bool
+	}{
+		{
+			name: "no flags",
+			want: &example{
+				Name:     "Simple Sam",
+				Age:      25,
+				Size:     1.87,
+				IsActive: true,
+				Hosts:    []string{"localhost", "127.0.0.1"},
+				Children: struct {
+					Name string
+					Age  int
+				}{Name: "Chris Sam", Age: 3},
+			},
+		},
+		{
+			name:    "flags take precedence over file and env",
+			args:    []string{"--name", "Flag Sam", "--age=40", "--isactive=false", "--children-age", "4"},
+			environ: []string{"CFG_NAME=Env Sam", "CFG_AGE=30", "CFG_SIZE=1.5"},
+			want: &example{
+				Name:  "Flag Sam",
+				Age:   40,
+				Size:  1.5,
+				Hosts: []string{"localhost", "127.0.0.1"},
+				Children: struct {
+					Name string
+					Age  int
+				}{Name: "Chris Sam", Age: 4},
+			},
+		},
+		{
+			name: "repeated slice and map flags",
+			args: []string{"--hosts", "a", "--hosts", "b;c", "--labels", "team=core", "--labels", "tier=1"},
+			want: &example{
+				Name:     "Simple Sam",
+				Age:      25,
+				Size:     1.87,
+				IsActive: true,
+				Hosts:    []string{"a", "b", "c"},
+				Labels:   map[string]string{"team": "core", "tier": "1"},
+				Children: struct {
+					Name string
+					Age  int
+				}{Name: "Chris Sam", Age: 3},
+			},
+		},
+		{
+			name:    "invalid value",
+			args:    []string{"--age", "old"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"--missing", "1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(ioutil.Discard)
+			l := NewLoader(WithFlagSet(fs), WithEnviron(func() []string { return tt.environ }))
+			got := &example{}
+			err := l.RegisterFlags(got)
+			if err != nil {
+				t.Fatalf("Loader.RegisterFlags() error = %v", err)
+			}
+			err = fs.Parse(tt.args)
+			if err == nil {
+				err = l.Load(".file/simple.yml", got)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Loader.Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.Load() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadFlagsBool(t *testing.T) {
+	type example struct {
+		Debug bool
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	l := NewLoader(WithFlagSet(fs), WithEnviron(func() []string { return nil }))
+	got := &example{}
+	err := l.RegisterFlags(got)
+	if err != nil {
+		t.Fatalf("Loader.RegisterFlags() error = %v", err)
+	}
+	err = fs.Parse([]string{"--debug", "rest"})
+	if err != nil {
+		t.Fatalf("FlagSet.Parse() error = %v", err)
+	}
+	err = l.LoadBytes([]byte("debug: false\n"), YAML, got)
+	if err != nil {
+		t.Fatalf("Loader.LoadBytes() error = %v", err)
+	}
+	if !got.Debug {
+		t.Errorf("Loader.LoadBytes() Debug = false, want true")
+	}
+	if diff := cmp.Diff([]string{"rest"}, fs.Args()); diff != "" {
+		t.Errorf("FlagSet.Args() mismatch (-want +got):\n%s", diff)
+	}
+}
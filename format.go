@@ -0,0 +1,274 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/hcl"
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the name of a config file format. Formats registered by RegisterFormat are passed by their name,
+// e.g. config.Format("ini").
+type Format string
+
+const (
+	YAML Format = "yaml"
+	JSON Format = "json"
+	TOML Format = "toml"
+	HCL  Format = "hcl"
+)
+
+// Decoder decodes the content of a config file into the receiver.
+type Decoder interface {
+	Decode(data []byte, receiver interface{}) error
+}
+
+// Encoder encodes a config into the content of a config file.
+type Encoder interface {
+	Encode(value interface{}) ([]byte, error)
+}
+
+// DecoderFunc is an adapter to use an ordinary function as Decoder, e.g. DecoderFunc(json.Unmarshal).
+type DecoderFunc func(data []byte, receiver interface{}) error
+
+// Decode calls f(data, receiver).
+func (f DecoderFunc) Decode(data []byte, receiver interface{}) error {
+	return f(data, receiver)
+}
+
+// EncoderFunc is an adapter to use an ordinary function as Encoder, e.g. EncoderFunc(json.Marshal).
+type EncoderFunc func(value interface{}) ([]byte, error)
+
+// Encode calls f(value).
+func (f EncoderFunc) Encode(value interface{}) ([]byte, error) {
+	return f(value)
+}
+
+// codec combines a decoder and an encoder function of a format.
+type codec struct {
+	decode DecoderFunc
+	encode EncoderFunc
+}
+
+// Decode calls the decoder function of the codec.
+func (c codec) Decode(data []byte, receiver interface{}) error {
+	return c.decode(data, receiver)
+}
+
+// Encode calls the encoder function of the codec.
+func (c codec) Encode(value interface{}) ([]byte, error) {
+	return c.encode(value)
+}
+
+// registeredFormat is a format known to the registry.
+type registeredFormat struct {
+	name       Format
+	extensions []string
+	decoder    Decoder
+	// parse parses the content into a document, used to normalize the time types of the built-in formats.
+	// It is nil for formats registered by RegisterFormat.
+	parse func(bts []byte) (document, error)
+}
+
+// registry holds all formats that can be loaded, indexed by name and file extension.
+var registry = struct {
+	sync.RWMutex
+	formats    map[Format]*registeredFormat
+	extensions map[string]Format
+}{
+	formats:    map[Format]*registeredFormat{},
+	extensions: map[string]Format{},
+}
+
+func init() {
+	register(&registeredFormat{
+		name:       YAML,
+		extensions: []string{".yaml", ".yml"},
+		decoder:    codec{decode: yaml.Unmarshal, encode: yaml.Marshal},
+		parse:      parseYAMLDocument,
+	})
+	register(&registeredFormat{
+		name:       JSON,
+		extensions: []string{".json"},
+		decoder: codec{decode: json.Unmarshal, encode: func(value interface{}) ([]byte, error) {
+			return json.MarshalIndent(value, "", "    ")
+		}},
+		parse: parseJSONDocument,
+	})
+	register(&registeredFormat{
+		name:       TOML,
+		extensions: []string{".toml"},
+		decoder:    codec{decode: toml.Unmarshal, encode: toml.Marshal},
+		parse:      parseTOMLDocument,
+	})
+	register(&registeredFormat{
+		name:       HCL,
+		extensions: []string{".hcl"},
+		decoder: codec{
+			decode: func(data []byte, receiver interface{}) error {
+				return hcl.Unmarshal(data, receiver)
+			},
+			encode: func(value interface{}) (bts []byte, err error) {
+				defer func() {
+					// hcl.Marshal panics for types it can not represent. Loader.Marshal checks the value beforehand,
+					// so this only covers types that are not known to fail
+					if r := recover(); r != nil {
+						err = fmt.Errorf("failed to encode hcl: %v", r)
+					}
+				}()
+				return hcl.Marshal(value)
+			},
+		},
+		parse: parseHCLDocument,
+	})
+}
+
+// RegisterFormat registers a format, so that files with one of the extensions are decoded with the decoder.
+// Registering an existing name replaces the format, including the built-in ones. An extension that is already
+// registered for another format is taken over by the new format.
+// If the decoder also implements Encoder, it is used to encode configs of the format.
+// @name: The name of the format, e.g. "ini". It can be passed to WithFormats.
+// @extensions: The file extensions of the format including the leading dot, e.g. ".ini".
+// @decoder: The decoder of the format.
+func RegisterFormat(name Format, extensions []string, decoder Decoder) error {
+	if name == "" {
+		return fmt.Errorf("format name must not be empty")
+	}
+	if decoder == nil {
+		return fmt.Errorf("decoder of format %s must not be nil", name)
+	}
+	exts := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		if ext == "" {
+			return fmt.Errorf("extension of format %s must not be empty", name)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		exts = append(exts, ext)
+	}
+	register(&registeredFormat{name: name, extensions: exts, decoder: decoder})
+	return nil
+}
+
+// register adds rf to the registry and replaces a format with the same name.
+func register(rf *registeredFormat) {
+	registry.Lock()
+	defer registry.Unlock()
+	for ext, f := range registry.extensions {
+		if f == rf.name {
+			delete(registry.extensions, ext)
+		}
+	}
+	registry.formats[rf.name] = rf
+	for _, ext := range rf.extensions {
+		registry.extensions[ext] = rf.name
+	}
+}
+
+// lookupFormat returns the registered format f.
+func lookupFormat(f Format) (*registeredFormat, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	rf, ok := registry.formats[f]
+	return rf, ok
+}
+
+// registeredExtensions returns the extensions of all registered formats in lexical order.
+func registeredExtensions() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	exts := make([]string, 0, len(registry.extensions))
+	for ext := range registry.extensions {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// detectFormat detects the format of the config file by its extension.
+// An empty format is returned if no format is registered for the extension.
+// @filePath: The path to the config file.
+func detectFormat(filePath string) Format {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.extensions[path.Ext(filePath)]
+}
+
+// unmarshal decodes bts of the format f into the receiver.
+// @bts: The content of the config file.
+// @receiver: The receiver to decode the config file into.
+// @f: The format of the config file.
+func unmarshal(bts []byte, receiver interface{}, f Format) error {
+	rf, ok := lookupFormat(f)
+	if !ok {
+		return fmt.Errorf("unsupported format: %s", f)
+	}
+	return rf.decoder.Decode(bts, receiver)
+}
+
+// marshal encodes the value in the format f.
+// @value: The value to encode.
+// @f: The format to encode the value in.
+func marshal(value interface{}, f Format) ([]byte, error) {
+	rf, ok := lookupFormat(f)
+	if !ok {
+		return nil, fmt.Errorf("unsupported format: %s", f)
+	}
+	encoder, ok := rf.decoder.(Encoder)
+	if !ok {
+		return nil, fmt.Errorf("format %s does not support encoding", f)
+	}
+	return encoder.Encode(value)
+}
+
+// parseYAMLDocument parses bts into a yamlDocument.
+// A nil document is returned if the content is not a mapping.
+func parseYAMLDocument(bts []byte) (document, error) {
+	node := &yaml.Node{}
+	err := yaml.Unmarshal(bts, node)
+	if err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.DocumentNode || len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	return &yamlDocument{node: node.Content[0]}, nil
+}
+
+// parseJSONDocument parses bts into a jsonDocument.
+func parseJSONDocument(bts []byte) (document, error) {
+	m := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(bts))
+	dec.UseNumber()
+	err := dec.Decode(&m)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonDocument{m: m}, nil
+}
+
+// parseTOMLDocument parses bts into a tomlDocument.
+func parseTOMLDocument(bts []byte) (document, error) {
+	tree, err := toml.LoadBytes(bts)
+	if err != nil {
+		return nil, err
+	}
+	return &tomlDocument{tree: tree}, nil
+}
+
+// parseHCLDocument parses bts into a hclDocument.
+func parseHCLDocument(bts []byte) (document, error) {
+	ast, err := hcl.ParseBytes(bts)
+	if err != nil {
+		return nil, err
+	}
+	return &hclDocument{ast: ast, entries: &ast.Entries}, nil
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// restoreRegistry resets the format registry to its state before the test.
+func restoreRegistry(t *testing.T) {
+	registry.Lock()
+	formats := map[Format]*registeredFormat{}
+	for k, v := range registry.formats {
+		formats[k] = v
+	}
+	extensions := map[string]Format{}
+	for k, v := range registry.extensions {
+		extensions[k] = v
+	}
+	registry.Unlock()
+	t.Cleanup(func() {
+		registry.Lock()
+		defer registry.Unlock()
+		registry.formats = formats
+		registry.extensions = extensions
+	})
+}
+
+// decodeKeyValue decodes lines of the form key=value into the string fields of the receiver.
+func decodeKeyValue(data []byte, receiver interface{}) error {
+	m := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			continue
+		}
+		m[strings.TrimSpace(line[:idx])] = strings.TrimSpace(line[idx+1:])
+	}
+	bts, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bts, receiver)
+}
+
+func TestRegisterFormat(t *testing.T) {
+	type args struct {
+		name       Format
+		extensions []string
+		decoder    Decoder
+	}
+	tests := []struct {
+		name     string
+		args     args
+		filePath string
+		want     Format
+		wantErr  bool
+	}{
+		{
+			name: "new format",
+			args: args{
+				name:       "kv",
+				extensions: []string{".kv", "properties"},
+				decoder:    DecoderFunc(decodeKeyValue),
+			},
+			filePath: "app.properties",
+			want:     "kv",
+		},
+		{
+			name: "take over extension",
+			args: args{
+				name:       "yaml11",
+				extensions: []string{".yml"},
+				decoder:    DecoderFunc(decodeKeyValue),
+			},
+			filePath: "app.yml",
+			want:     "yaml11",
+		},
+		{
+			name: "empty name",
+			args: args{
+				extensions: []string{".kv"},
+				decoder:    DecoderFunc(decodeKeyValue),
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil decoder",
+			args: args{
+				name:       "kv",
+				extensions: []string{".kv"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty extension",
+			args: args{
+				name:       "kv",
+				extensions: []string{""},
+				decoder:    DecoderFunc(decodeKeyValue),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreRegistry(t)
+			err := RegisterFormat(tt.args.name, tt.args.extensions, tt.args.decoder)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RegisterFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := detectFormat(tt.filePath); got != tt.want {
+				t.Errorf("detectFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterFormatLoad(t *testing.T) {
+	type example struct {
+		Name string `json:"name"`
+		Host string `json:"host"`
+	}
+	restoreRegistry(t)
+	err := RegisterFormat("kv", []string{".kv"}, DecoderFunc(decodeKeyValue))
+	if err != nil {
+		t.Fatalf("RegisterFormat() error = %v", err)
+	}
+	filePath := filepath.Join(t.TempDir(), "app.kv")
+	err = ioutil.WriteFile(filePath, []byte("name = Simple Sam\nhost = localhost\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := &example{}
+	l := NewLoader(WithEnviron(func() []string { return []string{"CFG_HOST=127.0.0.1"} }))
+	err = l.Load(filePath, got)
+	if err != nil {
+		t.Fatalf("Loader.Load() error = %v", err)
+	}
+	want := &example{Name: "Simple Sam", Host: "127.0.0.1"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Loader.Load() mismatch (-want +got):\n%s", diff)
+	}
+
+	err = NewLoader(WithFormats(YAML)).Load(filePath, &example{})
+	if err == nil {
+		t.Errorf("Loader.Load() expected an error for a format that is not accepted")
+	}
+}
+
+func TestRegisterFormatReplaceBuiltin(t *testing.T) {
+	type example struct {
+		Name string `json:"name"`
+	}
+	restoreRegistry(t)
+	called := false
+	err := RegisterFormat(JSON, []string{".json"}, DecoderFunc(func(data []byte, receiver interface{}) error {
+		called = true
+		return json.Unmarshal(data, receiver)
+	}))
+	if err != nil {
+		t.Fatalf("RegisterFormat() error = %v", err)
+	}
+	got := &example{}
+	err = NewLoader(WithEnviron(func() []string { return nil })).Load(".file/simple.json", got)
+	if err != nil {
+		t.Fatalf("Loader.Load() error = %v", err)
+	}
+	if !called {
+		t.Errorf("Loader.Load() did not use the registered decoder")
+	}
+	if got.Name != "Simple Sam" {
+		t.Errorf("Loader.Load() name = %q, want %q", got.Name, "Simple Sam")
+	}
+}
@@ -3,19 +3,19 @@ module github.com/leonsteinhaeuser/go-config
 go 1.17
 
 require (
+	github.com/alecthomas/hcl v0.4.0
 	github.com/google/go-cmp v0.5.7
 	github.com/hashicorp/hcl v1.0.0
 	github.com/pelletier/go-toml v1.9.4
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
 
 require (
-	github.com/alecthomas/hcl v0.4.0 // indirect
 	github.com/alecthomas/participle v0.6.1-0.20200911005820-318127ca69ac // indirect
 	github.com/alecthomas/repr v0.0.0-20200325044227-4184120f674c // indirect
 	github.com/k0kubun/pp v3.0.1+incompatible // indirect
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	golang.org/x/sys v0.0.0-20210927094055-39ccf1dd6fa6 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
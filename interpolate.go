@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithInterpolation enables the interpolation of env variables in the content of config files before they are decoded.
+// The default is false.
+//
+// Supported expressions:
+// - ${VAR}: the value of VAR or an empty string if VAR is not set.
+// - ${VAR:-default}: the value of VAR or default if VAR is not set or empty. The default is interpolated as well.
+// - ${VAR:?message}: the value of VAR or an error with the message if VAR is not set or empty.
+// - $$: a literal $, e.g. $${VAR} results in ${VAR}.
+//
+// The content is interpolated as text, so values that contain characters with a special meaning in the format,
+// e.g. quotes in a JSON string, must be escaped in the env variable. Expressions must not span multiple lines.
+// Lines that only contain a comment, i.e. start with # or //, are not interpolated, so they may mention expressions.
+func WithInterpolation(enabled bool) Option {
+	return func(l *Loader) {
+		l.interpolation = enabled
+	}
+}
+
+// interpolate replaces the expressions in s with the values returned by lookup, see WithInterpolation.
+// The content is interpolated line by line and comment lines are skipped. Errors contain the line of the invalid expression.
+// @s: The content to interpolate.
+// @lookup: The function that returns the value of an env variable and whether it is set.
+func interpolate(s string, lookup func(name string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	b := strings.Builder{}
+	b.Grow(len(s))
+	for i, line := range strings.SplitAfter(s, "\n") {
+		if isCommentLine(line) {
+			b.WriteString(line)
+			continue
+		}
+		out, err := substitute(line, lookup)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", i+1, err)
+		}
+		b.WriteString(out)
+	}
+	return b.String(), nil
+}
+
+// isCommentLine reports whether the line only contains a comment of YAML, TOML or HCL, i.e. starts with # or //.
+func isCommentLine(line string) bool {
+	line = strings.TrimLeft(line, " \t")
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+}
+
+// substitute replaces the expressions in s with the values returned by lookup.
+// @s: The content to interpolate.
+// @lookup: The function that returns the value of an env variable and whether it is set.
+func substitute(s string, lookup func(name string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	b := strings.Builder{}
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated expression %q", strings.TrimSuffix(s[i:], "\n"))
+			}
+			value, err := expand(s[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// expand returns the value of the expression expr without the surrounding ${ and }.
+// @expr: The expression, e.g. VAR:-default.
+// @lookup: The function that returns the value of an env variable and whether it is set.
+func expand(expr string, lookup func(name string) (string, bool)) (string, error) {
+	n := 0
+	for n < len(expr) && isNameChar(expr[n], n == 0) {
+		n++
+	}
+	name, op := expr[:n], expr[n:]
+	if name == "" {
+		return "", fmt.Errorf("invalid variable name in expression ${%s}", expr)
+	}
+	value, ok := lookup(name)
+	switch {
+	case op == "":
+		return value, nil
+	case strings.HasPrefix(op, ":-"):
+		if ok && value != "" {
+			return value, nil
+		}
+		value, err := substitute(op[2:], lookup)
+		return value, err
+	case strings.HasPrefix(op, ":?"):
+		if ok && value != "" {
+			return value, nil
+		}
+		msg := op[2:]
+		if msg == "" {
+			msg = "is required"
+		}
+		return "", fmt.Errorf("variable %s: %s", name, msg)
+	default:
+		return "", fmt.Errorf("invalid expression ${%s}", expr)
+	}
+}
+
+// closingBrace returns the index of the brace that closes the expression starting at start or -1.
+// Nested expressions, e.g. in defaults, are skipped.
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isNameChar reports whether c is allowed in a variable name. Digits are not allowed as first character.
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || !first && c >= '0' && c <= '9'
+}
+
+// lineOf returns the line number of the offset within s, starting at 1.
+func lineOf(s string, offset int) int {
+	return strings.Count(s[:offset], "\n") + 1
+}
@@ -0,0 +1,358 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Loader loads config files into a receiver, enriches them with env variables and validates them.
+// A Loader is configured once by its options and can be used concurrently afterwards.
+type Loader struct {
+	prefix                  string
+	envDelimiter            string
+	envSliceDelimiter       string
+	envMapKeyValueDelimiter string
+	environ                 func() []string
+	// formats restricts the accepted formats, nil accepts all registered formats.
+	formats         []Format
+	format          Format
+	formatDetection FormatDetection
+	strict          bool
+	defaultsMode    DefaultsMode
+	slicePolicy     SliceMergePolicy
+	timeLayouts     []string
+	searchMode      SearchMode
+	dirRecursive    bool
+	dirPattern      string
+	interpolation   bool
+	dotenvs         []dotenvFile
+	flagSet         *flag.FlagSet
+	provenance      *Provenance
+	// rec is only set on the copy of a Loader that performs a single load, see recording.
+	rec *recorder
+}
+
+// Option configures a Loader.
+type Option func(*Loader)
+
+// WithEnvPrefix sets the prefix of the env variables. The default is CFG.
+func WithEnvPrefix(prefix string) Option {
+	return func(l *Loader) {
+		l.prefix = prefix
+	}
+}
+
+// WithEnvDelimiter sets the delimiter between the parts of an env variable name. The default is "_".
+func WithEnvDelimiter(delimiter string) Option {
+	return func(l *Loader) {
+		l.envDelimiter = delimiter
+	}
+}
+
+// WithEnvSliceDelimiter sets the delimiter between the elements of slices and map entries in env variables.
+// The default is ";".
+func WithEnvSliceDelimiter(delimiter string) Option {
+	return func(l *Loader) {
+		l.envSliceDelimiter = delimiter
+	}
+}
+
+// WithEnvMapKeyValueDelimiter sets the delimiter between the key and the value of map entries in env variables.
+// The default is "=".
+func WithEnvMapKeyValueDelimiter(delimiter string) Option {
+	return func(l *Loader) {
+		l.envMapKeyValueDelimiter = delimiter
+	}
+}
+
+// WithEnviron sets the function that returns the env variables in the form "key=value".
+// The default is os.Environ. The function is called once per load.
+func WithEnviron(environ func() []string) Option {
+	return func(l *Loader) {
+		l.environ = environ
+	}
+}
+
+// WithFormats restricts the formats of the config files that are accepted. By default all registered formats are accepted.
+func WithFormats(formats ...Format) Option {
+	return func(l *Loader) {
+		l.formats = formats
+	}
+}
+
+// WithFormat forces the format of all configs, regardless of their file extension and content.
+func WithFormat(f Format) Option {
+	return func(l *Loader) {
+		l.format = f
+	}
+}
+
+// WithFormatDetection defines how the format of a config is detected. The default is DetectByExtension.
+func WithFormatDetection(detection FormatDetection) Option {
+	return func(l *Loader) {
+		l.formatDetection = detection
+	}
+}
+
+// WithStrict defines whether env variables that can not be parsed result in an error.
+// If strict is false, these variables are skipped. The default is true.
+func WithStrict(strict bool) Option {
+	return func(l *Loader) {
+		l.strict = strict
+	}
+}
+
+// WithDefaultsMode defines when the values of the default tags are applied. The default is DefaultsBeforeParse.
+func WithDefaultsMode(mode DefaultsMode) Option {
+	return func(l *Loader) {
+		l.defaultsMode = mode
+	}
+}
+
+// WithSliceMergePolicy defines how slices are merged if multiple config files are loaded. The default is SliceReplace.
+func WithSliceMergePolicy(policy SliceMergePolicy) Option {
+	return func(l *Loader) {
+		l.slicePolicy = policy
+	}
+}
+
+// WithTimeLayouts sets the layouts that are tried in the given order to parse time.Time values.
+// The default is RFC3339, "2006-01-02 15:04:05" and "2006-01-02".
+func WithTimeLayouts(layouts ...string) Option {
+	return func(l *Loader) {
+		l.timeLayouts = layouts
+	}
+}
+
+// NewLoader returns a Loader that is configured by the given options.
+func NewLoader(opts ...Option) *Loader {
+	l := &Loader{
+		prefix:                  "CFG",
+		envDelimiter:            "_",
+		envSliceDelimiter:       ";",
+		envMapKeyValueDelimiter: "=",
+		environ:                 os.Environ,
+		strict:                  true,
+		defaultsMode:            DefaultsBeforeParse,
+		slicePolicy:             SliceReplace,
+		timeLayouts:             []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// defaultLoader returns a Loader that is configured by the deprecated package level variables
+// and the given options. It is used by the package level functions.
+func defaultLoader(opts ...Option) *Loader {
+	return NewLoader(append([]Option{
+		WithEnvDelimiter(EnvDelimeter),
+		WithEnvSliceDelimiter(EnvSliceDelimeter),
+		WithEnvMapKeyValueDelimiter(EnvMapKeyValueDelimeter),
+		WithStrict(!EnvIgnoreParseErrors),
+		WithDefaultsMode(DefaultsApplyMode),
+		WithTimeLayouts(TimeLayouts...),
+	}, opts...)...)
+}
+
+// Load parses the config file into the receiver and enriches the config with the value from env variables.
+// The format of the file is detected by its extension, see WithFormatDetection. Finally the receiver is validated, see Validate.
+// @filePath: The path to the config file.
+// @receiver: The receiver to parse the config file into.
+func (l *Loader) Load(filePath string, receiver interface{}) error {
+	return l.load(receiver, func(l *Loader) error {
+		return l.loadAndParseFile(filePath, receiver, detectFormat(filePath))
+	})
+}
+
+// LoadFS parses the config file of the file system fsys into the receiver, e.g. a file embedded with go:embed,
+// and enriches the config with the value from env variables. The format of the file is detected by its extension.
+// Finally the receiver is validated, see Validate.
+// @fsys: The file system to read the config file from.
+// @filePath: The slash separated path to the config file within fsys.
+// @receiver: The receiver to parse the config file into.
+func (l *Loader) LoadFS(fsys fs.FS, filePath string, receiver interface{}) error {
+	return l.load(receiver, func(l *Loader) error {
+		return l.loadAndParseFS(fsys, filePath, receiver, detectFormat(filePath))
+	})
+}
+
+// LoadReader parses the content of r in the format f into the receiver
+// and enriches the config with the value from env variables. Finally the receiver is validated, see Validate.
+// @r: The reader to read the config from. It is read until EOF.
+// @f: The format of the config. If it is empty, the format is detected by the content, see DetectByContent.
+// @receiver: The receiver to parse the config into.
+func (l *Loader) LoadReader(r io.Reader, f Format, receiver interface{}) error {
+	bts, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return l.LoadBytes(bts, f, receiver)
+}
+
+// LoadBytes parses the content bts in the format f into the receiver
+// and enriches the config with the value from env variables. Finally the receiver is validated, see Validate.
+// @bts: The content of the config.
+// @f: The format of the config. If it is empty, the format is detected by the content, see DetectByContent.
+// @receiver: The receiver to parse the config into.
+func (l *Loader) LoadBytes(bts []byte, f Format, receiver interface{}) error {
+	if f == "" && l.formatDetection == DetectByExtension {
+		// there is no file extension, so the format can only be detected by the content
+		lc := *l
+		lc.formatDetection = DetectByExtensionOrContent
+		l = &lc
+	}
+	return l.load(receiver, func(l *Loader) error {
+		return l.parseBytes(bts, receiver, f)
+	})
+}
+
+// load applies the defaults in DefaultsBeforeParse mode, calls parse and finishes the receiver.
+// @receiver: The receiver to load the config into.
+// @parse: The function that parses the config into the receiver with the Loader of the load, see recording.
+func (l *Loader) load(receiver interface{}, parse func(l *Loader) error) error {
+	l = l.recording()
+	if l.defaultsMode == DefaultsBeforeParse {
+		err := l.applyDefaults(receiver)
+		if err != nil {
+			return err
+		}
+	}
+	err := parse(l)
+	if err != nil {
+		return err
+	}
+	err = l.finish(receiver)
+	if err != nil {
+		return err
+	}
+	l.publish()
+	return nil
+}
+
+// LoadFiles parses multiple config files, merges them in the given order into the receiver
+// and enriches the config with the value from env variables. Finally the receiver is validated, see Validate.
+// Fields whose keys are not defined in a later file keep the value of an earlier file, while keys that are defined
+// override it, even with the zero value, e.g. debug: false. Formats without document, e.g. formats registered
+// by RegisterFormat, cannot tell unset keys from zero values, so zero values do not override earlier files.
+// @receiver: The receiver to parse the config files into.
+// @filePaths: The paths to the config files. Later files take precedence over earlier ones.
+func (l *Loader) LoadFiles(receiver interface{}, filePaths ...string) error {
+	val := reflect.ValueOf(receiver)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return fmt.Errorf("receiver must be a non-nil pointer, got: %T", receiver)
+	}
+	l = l.recording()
+	if l.defaultsMode == DefaultsBeforeParse {
+		err := l.applyDefaults(receiver)
+		if err != nil {
+			return err
+		}
+	}
+	for _, filePath := range filePaths {
+		layer := reflect.New(val.Elem().Type())
+		doc, err := l.loadLayer(filePath, layer.Interface())
+		if err != nil {
+			return fmt.Errorf("failed to load %q: %w", filePath, err)
+		}
+		mergeValues(val.Elem(), layer.Elem(), doc, l.slicePolicy)
+	}
+	err := l.finish(receiver)
+	if err != nil {
+		return err
+	}
+	l.publish()
+	return nil
+}
+
+// loadLayer parses the config file into the fresh receiver layer and returns the document of the file, see parseBytesDocument.
+// @filePath: The path to the config file.
+// @layer: The receiver to parse the config file into.
+func (l *Loader) loadLayer(filePath string, layer interface{}) (document, error) {
+	bts, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	l.rec.parsing(filePath)
+	return l.parseBytesDocument(bts, layer, detectFormat(filePath), true)
+}
+
+// finish applies the defaults in DefaultsOnZero mode, enriches the receiver with the env variables,
+// applies the flags and validates it.
+func (l *Loader) finish(receiver interface{}) error {
+	if l.defaultsMode == DefaultsOnZero {
+		err := l.applyDefaults(receiver)
+		if err != nil {
+			return err
+		}
+	}
+	err := l.readStructAndEnrichWithEnv(receiver, l.prefix)
+	if err != nil {
+		return err
+	}
+	err = l.applyFlags(receiver)
+	if err != nil {
+		return err
+	}
+	return Validate(receiver)
+}
+
+// supportsFormat reports whether the format f is accepted by the Loader.
+func (l *Loader) supportsFormat(f Format) bool {
+	if _, ok := lookupFormat(f); !ok {
+		return false
+	}
+	if l.formats == nil {
+		return true
+	}
+	for _, supported := range l.formats {
+		if supported == f {
+			return true
+		}
+	}
+	return false
+}
+
+// envSnapshot holds the env variables of a single load, so that all fields see the same state.
+type envSnapshot struct {
+	vars map[string]string
+	// names contains the names of all variables in lexical order.
+	names []string
+}
+
+// environVars returns the variables of the process environment of the Loader, see WithEnviron.
+func (l *Loader) environVars() map[string]string {
+	vars := map[string]string{}
+	for _, env := range l.environ() {
+		idx := strings.Index(env, "=")
+		if idx < 0 {
+			continue
+		}
+		vars[env[:idx]] = env[idx+1:]
+	}
+	return vars
+}
+
+// snapshotEnv returns the current env variables of the Loader including the variables of the dotenv files.
+func (l *Loader) snapshotEnv() (*envSnapshot, error) {
+	vars := l.environVars()
+	err := l.applyDotenvs(vars)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &envSnapshot{vars: vars}
+	for name := range vars {
+		snapshot.names = append(snapshot.names, name)
+	}
+	sort.Strings(snapshot.names)
+	return snapshot, nil
+}
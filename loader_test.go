@@ -0,0 +1,524 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoader_Load(t *testing.T) {
+	type example struct {
+		Name     string
+		Age      int
+		Hosts    []string
+		Labels   map[string]string
+		Birthday time.Time
+	}
+	type args struct {
+		filePath string
+		opts     []Option
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *example
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			args: args{
+				filePath: ".file/simple.yml",
+				opts: []Option{
+					WithEnviron(func() []string { return []string{"CFG_AGE=30"} }),
+				},
+			},
+			want: &example{
+				Name:  "Simple Sam",
+				Age:   30,
+				Hosts: []string{"localhost", "127.0.0.1"},
+			},
+		},
+		{
+			name: "custom prefix and delimiters",
+			args: args{
+				filePath: ".file/simple.yml",
+				opts: []Option{
+					WithEnvPrefix("app"),
+					WithEnvDelimiter("__"),
+					WithEnvSliceDelimiter(","),
+					WithEnvMapKeyValueDelimiter(":"),
+					WithEnviron(func() []string {
+						return []string{"APP__HOSTS=a,b", "APP__LABELS=team:core,tier:1", "APP_AGE=1", "APP__AGE=31"}
+					}),
+				},
+			},
+			want: &example{
+				Name:   "Simple Sam",
+				Age:    31,
+				Hosts:  []string{"a", "b"},
+				Labels: map[string]string{"team": "core", "tier": "1"},
+			},
+		},
+		{
+			name: "custom time layouts",
+			args: args{
+				filePath: ".file/simple.yml",
+				opts: []Option{
+					WithTimeLayouts("02.01.2006"),
+					WithEnviron(func() []string { return []string{"CFG_BIRTHDAY=24.12.1990"} }),
+				},
+			},
+			want: &example{
+				Name:     "Simple Sam",
+				Age:      25,
+				Hosts:    []string{"localhost", "127.0.0.1"},
+				Birthday: time.Date(1990, 12, 24, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "strict with invalid env",
+			args: args{
+				filePath: ".file/simple.yml",
+				opts: []Option{
+					WithEnviron(func() []string { return []string{"CFG_AGE=thirty"} }),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "lenient with invalid env",
+			args: args{
+				filePath: ".file/simple.yml",
+				opts: []Option{
+					WithStrict(false),
+					WithEnviron(func() []string { return []string{"CFG_AGE=thirty", "CFG_NAME=Sam"} }),
+				},
+			},
+			want: &example{
+				Name:  "Sam",
+				Age:   25,
+				Hosts: []string{"localhost", "127.0.0.1"},
+			},
+		},
+		{
+			name: "format not allowed",
+			args: args{
+				filePath: ".file/simple.yml",
+				opts:     []Option{WithFormats(JSON, TOML)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "format allowed",
+			args: args{
+				filePath: ".file/simple.json",
+				opts: []Option{
+					WithFormats(JSON),
+					WithEnviron(func() []string { return nil }),
+				},
+			},
+			want: &example{
+				Name:  "Simple Sam",
+				Age:   25,
+				Hosts: []string{"localhost", "127.0.0.1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &example{}
+			err := NewLoader(tt.args.opts...).Load(tt.args.filePath, got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Loader.Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.Load() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadFiles(t *testing.T) {
+	type example struct {
+		Name  string
+		Hosts []string
+	}
+	got := &example{}
+	l := NewLoader(
+		WithSliceMergePolicy(SliceAppend),
+		WithEnviron(func() []string { return nil }),
+	)
+	err := l.LoadFiles(got, ".file/simple.yml", ".file/simple.json")
+	if err != nil {
+		t.Fatalf("Loader.LoadFiles() error = %v", err)
+	}
+	want := &example{
+		Name:  "Simple Sam",
+		Hosts: []string{"localhost", "127.0.0.1", "localhost", "127.0.0.1"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Loader.LoadFiles() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoader_LoadFilesZeroValues(t *testing.T) {
+	type server struct {
+		Host string `hcl:"host,optional"`
+		Port int    `hcl:"port,optional"`
+	}
+	type example struct {
+		Name      string            `hcl:"name,optional"`
+		Debug     bool              `hcl:"debug,optional"`
+		Port      int               `hcl:"port,optional"`
+		Hosts     []string          `hcl:"hosts,optional"`
+		Server    server            `hcl:"server,block"`
+		Upstreams map[string]server `hcl:"upstreams,optional"`
+	}
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  *example
+	}{
+		{
+			name: "yaml",
+			files: map[string]string{
+				"a.yml": "debug: true\nport: 80\nhosts: [a]\nserver: {host: h, port: 1}\n",
+				"b.yml": "debug: false\nport: 0\nhosts: []\nserver: {port: 0}\n",
+			},
+			want: &example{Hosts: []string{}, Server: server{Host: "h"}},
+		},
+		{
+			name: "json",
+			files: map[string]string{
+				"a.json": `{"debug": true, "port": 80, "server": {"host": "h", "port": 1}}`,
+				"b.json": `{"debug": false, "port": 0, "server": {"port": 0}}`,
+			},
+			want: &example{Server: server{Host: "h"}},
+		},
+		{
+			name: "toml",
+			files: map[string]string{
+				"a.toml": "debug = true\nport = 80\n[server]\nhost = \"h\"\nport = 1\n",
+				"b.toml": "debug = false\nport = 0\n[server]\nport = 0\n",
+			},
+			want: &example{Server: server{Host: "h"}},
+		},
+		{
+			name: "hcl",
+			files: map[string]string{
+				"a.hcl": "debug = true\nport = 80\nserver {\n  host = \"h\"\n  port = 1\n}\n",
+				"b.hcl": "debug = false\nport = 0\nserver {\n  port = 0\n}\n",
+			},
+			want: &example{Server: server{Host: "h"}},
+		},
+		{
+			name: "keys that are not defined keep their value",
+			files: map[string]string{
+				"a.yml": "name: a\ndebug: true\nport: 80\n",
+				"b.yml": "name: b\n",
+			},
+			want: &example{Name: "b", Debug: true, Port: 80},
+		},
+		{
+			name: "map entries are merged by key",
+			files: map[string]string{
+				"a.yml": "upstreams: {a: {host: x, port: 1}, b: {host: y}}\n",
+				"b.yml": "upstreams: {a: {port: 0}}\n",
+			},
+			want: &example{Upstreams: map[string]server{"a": {Host: "x"}, "b": {Host: "y"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePaths := []string{}
+			for name, content := range tt.files {
+				filePath := filepath.Join(dir, name)
+				err := ioutil.WriteFile(filePath, []byte(content), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				filePaths = append(filePaths, filePath)
+			}
+			sort.Strings(filePaths)
+			got := &example{}
+			err := NewLoader(WithEnviron(func() []string { return nil })).LoadFiles(got, filePaths...)
+			if err != nil {
+				t.Fatalf("Loader.LoadFiles() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.LoadFiles() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadFilesEmbedded(t *testing.T) {
+	type Base struct {
+		Name string
+		Port int
+	}
+	type example struct {
+		Base  `yaml:",inline"`
+		Extra string
+	}
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  *example
+	}{
+		{
+			name: "yaml",
+			files: map[string]string{
+				"a.yml": "name: a\nport: 80\nextra: a\n",
+				"b.yml": "port: 0\nextra: b\n",
+			},
+			want: &example{Base: Base{Name: "a"}, Extra: "b"},
+		},
+		{
+			name: "json",
+			files: map[string]string{
+				"a.json": `{"name": "a", "port": 80, "extra": "a"}`,
+				"b.json": `{"port": 0, "extra": "b"}`,
+			},
+			want: &example{Base: Base{Name: "a"}, Extra: "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePaths := []string{}
+			for name, content := range tt.files {
+				filePath := filepath.Join(dir, name)
+				err := ioutil.WriteFile(filePath, []byte(content), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				filePaths = append(filePaths, filePath)
+			}
+			sort.Strings(filePaths)
+			got := &example{}
+			err := NewLoader(WithEnviron(func() []string { return nil })).LoadFiles(got, filePaths...)
+			if err != nil {
+				t.Fatalf("Loader.LoadFiles() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.LoadFiles() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_Concurrent(t *testing.T) {
+	type example struct {
+		Name string
+		Age  int
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l := NewLoader(WithEnviron(func() []string { return []string{fmt.Sprintf("CFG_AGE=%d", i)} }))
+			got := &example{}
+			err := l.Load(".file/simple.yml", got)
+			if err != nil {
+				t.Errorf("Loader.Load() error = %v", err)
+				return
+			}
+			if got.Age != i {
+				t.Errorf("Loader.Load() age = %d, want %d", got.Age, i)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestLoader_LoadReader(t *testing.T) {
+	type example struct {
+		Name string `hcl:"name"`
+		Age  int    `hcl:"age"`
+	}
+	type args struct {
+		content string
+		f       Format
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *example
+		wantErr bool
+	}{
+		{
+			name: "yaml",
+			args: args{
+				content: "name: Simple Sam\nage: 25\n",
+				f:       YAML,
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+		{
+			name: "json",
+			args: args{
+				content: `{"name": "Simple Sam", "age": 25}`,
+				f:       JSON,
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+		{
+			name: "toml",
+			args: args{
+				content: "name = \"Simple Sam\"\nage = 25\n",
+				f:       TOML,
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+		{
+			name: "hcl",
+			args: args{
+				content: "name = \"Simple Sam\"\nage = 25\n",
+				f:       HCL,
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+		{
+			name: "detected yaml",
+			args: args{
+				content: "name: Simple Sam\nage: 25\n",
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+		{
+			name: "detected json",
+			args: args{
+				content: `{"name": "Simple Sam", "age": 25}`,
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+		{
+			name: "undetectable content",
+			args: args{
+				content: "Simple Sam",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported format",
+			args: args{
+				content: "name: Simple Sam\n",
+				f:       "ini",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid content",
+			args: args{
+				content: `{"name": `,
+				f:       JSON,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &example{}
+			l := NewLoader(WithEnviron(func() []string { return nil }))
+			err := l.LoadReader(strings.NewReader(tt.args.content), tt.args.f, got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Loader.LoadReader() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.LoadReader() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadFS(t *testing.T) {
+	type example struct {
+		Name string
+		Age  int
+	}
+	type args struct {
+		fsys     fs.FS
+		filePath string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *example
+		wantErr bool
+	}{
+		{
+			name: "map fs",
+			args: args{
+				fsys: fstest.MapFS{
+					"config/app.json": &fstest.MapFile{Data: []byte(`{"name": "Simple Sam", "age": 25}`)},
+				},
+				filePath: "config/app.json",
+			},
+			want: &example{Name: "Simple Sam", Age: 30},
+		},
+		{
+			name: "dir fs",
+			args: args{
+				fsys:     os.DirFS(".file"),
+				filePath: "simple.yml",
+			},
+			want: &example{Name: "Simple Sam", Age: 30},
+		},
+		{
+			name: "missing file",
+			args: args{
+				fsys:     fstest.MapFS{},
+				filePath: "app.json",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown extension",
+			args: args{
+				fsys: fstest.MapFS{
+					"app.conf": &fstest.MapFile{Data: []byte(`{"name": "Simple Sam"}`)},
+				},
+				filePath: "app.conf",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &example{}
+			l := NewLoader(WithEnviron(func() []string { return []string{"CFG_AGE=30"} }))
+			err := l.LoadFS(tt.args.fsys, tt.args.filePath, got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Loader.LoadFS() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.LoadFS() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SliceMergePolicy defines how slices are merged when multiple config files are layered.
+type SliceMergePolicy int
+
+const (
+	// SliceReplace replaces the slice of the previous layer with the slice of the next layer.
+	SliceReplace SliceMergePolicy = iota
+	// SliceAppend appends the slice of the next layer to the slice of the previous layer.
+	SliceAppend
+)
+
+// mergeValues deep merges src into dst, so that a later layer only overrides what it actually defines.
+// If doc is not nil, all fields whose keys are present in doc are merged, even if they hold the zero value.
+// Without document, fields that hold the zero value in src are skipped, since they cannot be told apart from unset fields.
+// @dst: The value to merge into. Must be settable.
+// @src: The value to merge from.
+// @doc: The document src has been decoded from, nil if the format has no document.
+// @policy: The policy to use for slices.
+func mergeValues(dst, src reflect.Value, doc document, policy SliceMergePolicy) {
+	if doc != nil && src.Kind() == reflect.Struct {
+		mergeDocumentFields(dst, src, doc, policy)
+		return
+	}
+	switch src.Kind() {
+	case reflect.Struct:
+		if isScalarType(src.Type()) {
+			// scalar types are merged as a whole
+			if !src.IsZero() {
+				dst.Set(src)
+			}
+			return
+		}
+		for i := 0; i < src.NumField(); i++ {
+			if !dst.Field(i).CanSet() {
+				// unexported field
+				continue
+			}
+			mergeValues(dst.Field(i), src.Field(i), nil, policy)
+		}
+	case reflect.Map:
+		if src.Len() == 0 {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		}
+		iter := src.MapRange()
+		for iter.Next() {
+			existing := dst.MapIndex(iter.Key())
+			if !existing.IsValid() {
+				dst.SetMapIndex(iter.Key(), iter.Value())
+				continue
+			}
+			// map elements are not addressable, so we merge into a copy
+			merged := reflect.New(existing.Type()).Elem()
+			merged.Set(existing)
+			mergeValues(merged, iter.Value(), nil, policy)
+			dst.SetMapIndex(iter.Key(), merged)
+		}
+	case reflect.Slice:
+		if src.Len() == 0 {
+			return
+		}
+		if policy == SliceAppend && !dst.IsNil() {
+			dst.Set(reflect.AppendSlice(dst, src))
+			return
+		}
+		dst.Set(src)
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(src)
+			return
+		}
+		mergeValues(dst.Elem(), src.Elem(), nil, policy)
+	default:
+		if src.IsZero() {
+			return
+		}
+		dst.Set(src)
+	}
+}
+
+// mergeDocumentFields merges all fields of the struct src whose keys are present in doc into dst.
+// @dst: The struct value to merge into. Must be settable.
+// @src: The struct value to merge from.
+// @doc: The document src has been decoded from.
+// @policy: The policy to use for slices.
+func mergeDocumentFields(dst, src reflect.Value, doc document, policy SliceMergePolicy) {
+	for i := 0; i < src.NumField(); i++ {
+		field := src.Type().Field(i)
+		if isPromotedField(doc, field) {
+			// the exported fields of unexported embedded structs are promoted as well
+			mergePromotedFields(dst.Field(i), src.Field(i), doc, policy)
+			continue
+		}
+		if !dst.Field(i).CanSet() {
+			// unexported field
+			continue
+		}
+		if _, ok := doc.position(field); !ok {
+			continue
+		}
+		child, _ := doc.child(field)
+		mergePresentValue(dst.Field(i), src.Field(i), child, policy)
+	}
+}
+
+// mergePromotedFields merges the embedded or inlined struct src, whose keys are part of doc, into dst.
+// Nil pointers in src are skipped, nil pointers in dst are allocated.
+// @dst: The struct value or pointer to merge into. Must be settable.
+// @src: The struct value or pointer to merge from.
+// @doc: The document that contains the keys of src.
+// @policy: The policy to use for slices.
+func mergePromotedFields(dst, src reflect.Value, doc document, policy SliceMergePolicy) {
+	for src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			if !dst.CanSet() {
+				// unexported embedded pointer
+				return
+			}
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		src, dst = src.Elem(), dst.Elem()
+	}
+	mergeDocumentFields(dst, src, doc, policy)
+}
+
+// mergePresentValue merges src, whose key is present in the document, into dst. Zero values override dst as well.
+// Structs and maps with nested document are merged key by key, all other values are replaced according to the policy.
+// @dst: The value to merge into. Must be settable.
+// @src: The value to merge from.
+// @doc: The nested document of src, nil if src is not a struct or map in the document.
+// @policy: The policy to use for slices.
+func mergePresentValue(dst, src reflect.Value, doc document, policy SliceMergePolicy) {
+	switch {
+	case doc == nil:
+		if src.Kind() == reflect.Slice && policy == SliceAppend && !dst.IsNil() {
+			dst.Set(reflect.AppendSlice(dst, src))
+			return
+		}
+		dst.Set(src)
+	case src.Kind() == reflect.Ptr:
+		if dst.IsNil() || src.IsNil() {
+			dst.Set(src)
+			return
+		}
+		mergePresentValue(dst.Elem(), src.Elem(), doc, policy)
+	case src.Kind() == reflect.Struct && !isScalarType(src.Type()):
+		mergeDocumentFields(dst, src, doc, policy)
+	case src.Kind() == reflect.Map:
+		if dst.IsNil() || src.IsNil() {
+			dst.Set(src)
+			return
+		}
+		iter := src.MapRange()
+		for iter.Next() {
+			existing := dst.MapIndex(iter.Key())
+			// entries are looked up like fields without tag
+			entry, ok := doc.child(reflect.StructField{Name: fmt.Sprint(iter.Key().Interface())})
+			if !existing.IsValid() || !ok {
+				dst.SetMapIndex(iter.Key(), iter.Value())
+				continue
+			}
+			// map elements are not addressable, so we merge into a copy
+			merged := reflect.New(existing.Type()).Elem()
+			merged.Set(existing)
+			mergePresentValue(merged, iter.Value(), entry, policy)
+			dst.SetMapIndex(iter.Key(), merged)
+		}
+	default:
+		dst.Set(src)
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type mergeExample struct {
+	Name   string
+	Port   int
+	Labels map[string]string
+	Nested map[string]ExampleConfigB
+	Hosts  []string
+	Ptr    *ExampleConfigB
+	Child  ExampleConfigB
+}
+
+func Test_mergeValues(t *testing.T) {
+	type args struct {
+		dst    *mergeExample
+		src    *mergeExample
+		policy SliceMergePolicy
+	}
+	tests := []struct {
+		name string
+		args args
+		want *mergeExample
+	}{
+		{
+			name: "empty src keeps dst",
+			args: args{
+				dst: &mergeExample{
+					Name:  "a",
+					Port:  80,
+					Hosts: []string{"a"},
+				},
+				src:    &mergeExample{},
+				policy: SliceReplace,
+			},
+			want: &mergeExample{
+				Name:  "a",
+				Port:  80,
+				Hosts: []string{"a"},
+			},
+		},
+		{
+			name: "scalars and nested structs",
+			args: args{
+				dst: &mergeExample{
+					Name:  "a",
+					Port:  80,
+					Child: ExampleConfigB{Name: "child", Age: 1},
+				},
+				src: &mergeExample{
+					Port:  8080,
+					Child: ExampleConfigB{Age: 2},
+				},
+				policy: SliceReplace,
+			},
+			want: &mergeExample{
+				Name:  "a",
+				Port:  8080,
+				Child: ExampleConfigB{Name: "child", Age: 2},
+			},
+		},
+		{
+			name: "maps are merged",
+			args: args{
+				dst: &mergeExample{
+					Labels: map[string]string{"team": "core", "tier": "1"},
+					Nested: map[string]ExampleConfigB{"a": {Name: "a", Age: 1}},
+				},
+				src: &mergeExample{
+					Labels: map[string]string{"tier": "2", "zone": "eu"},
+					Nested: map[string]ExampleConfigB{"a": {Age: 2}, "b": {Name: "b"}},
+				},
+				policy: SliceReplace,
+			},
+			want: &mergeExample{
+				Labels: map[string]string{"team": "core", "tier": "2", "zone": "eu"},
+				Nested: map[string]ExampleConfigB{"a": {Name: "a", Age: 2}, "b": {Name: "b"}},
+			},
+		},
+		{
+			name: "nil map in dst",
+			args: args{
+				dst: &mergeExample{},
+				src: &mergeExample{
+					Labels: map[string]string{"team": "core"},
+				},
+				policy: SliceReplace,
+			},
+			want: &mergeExample{
+				Labels: map[string]string{"team": "core"},
+			},
+		},
+		{
+			name: "slices replaced",
+			args: args{
+				dst:    &mergeExample{Hosts: []string{"a", "b"}},
+				src:    &mergeExample{Hosts: []string{"c"}},
+				policy: SliceReplace,
+			},
+			want: &mergeExample{Hosts: []string{"c"}},
+		},
+		{
+			name: "slices appended",
+			args: args{
+				dst:    &mergeExample{Hosts: []string{"a", "b"}},
+				src:    &mergeExample{Hosts: []string{"c"}},
+				policy: SliceAppend,
+			},
+			want: &mergeExample{Hosts: []string{"a", "b", "c"}},
+		},
+		{
+			name: "pointers",
+			args: args{
+				dst:    &mergeExample{Ptr: &ExampleConfigB{Name: "a", Age: 1}},
+				src:    &mergeExample{Ptr: &ExampleConfigB{Age: 2}},
+				policy: SliceReplace,
+			},
+			want: &mergeExample{Ptr: &ExampleConfigB{Name: "a", Age: 2}},
+		},
+		{
+			name: "nil pointer in dst",
+			args: args{
+				dst:    &mergeExample{},
+				src:    &mergeExample{Ptr: &ExampleConfigB{Age: 2}},
+				policy: SliceReplace,
+			},
+			want: &mergeExample{Ptr: &ExampleConfigB{Age: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mergeValues(reflect.ValueOf(tt.args.dst).Elem(), reflect.ValueOf(tt.args.src).Elem(), nil, tt.args.policy)
+			diff := cmp.Diff(tt.args.dst, tt.want)
+			if diff != "" {
+				t.Errorf("mergeValues() diff = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+// SourceKind is the kind of source that set the value of a field.
+type SourceKind int
+
+const (
+	// SourceDefault is the default tag of the field.
+	SourceDefault SourceKind = iota + 1
+	// SourceFile is a config file.
+	SourceFile
+	// SourceEnv is an env variable.
+	SourceEnv
+	// SourceFlag is a command-line flag.
+	SourceFlag
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case SourceDefault:
+		return "default"
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceFlag:
+		return "flag"
+	default:
+		return fmt.Sprintf("SourceKind(%d)", int(k))
+	}
+}
+
+// Source describes where the value of a field came from.
+type Source struct {
+	Kind SourceKind
+	// Name is the path of the config file, the name of the env variable or the flag, e.g. --server-port.
+	// It is empty for defaults and configs that have not been loaded from a file, e.g. by LoadBytes.
+	Name string
+	// Line is the line of the key in the config file. It is 0 if the format does not provide lines, e.g. JSON.
+	Line int
+}
+
+func (s Source) String() string {
+	switch {
+	case s.Kind == SourceDefault:
+		return "default tag"
+	case s.Kind == SourceFile && s.Name == "":
+		return "config"
+	case s.Kind == SourceFile && s.Line > 0:
+		return fmt.Sprintf("file %s:%d", s.Name, s.Line)
+	default:
+		return fmt.Sprintf("%s %s", s.Kind, s.Name)
+	}
+}
+
+// Provenance records which source set the value of every field during the last successful load of a Loader,
+// see WithProvenance. Fields are identified by their path, e.g. Server.Port, Upstreams[0].Host or Labels[team].
+// Fields that are set as a whole by a config file, e.g. slices and maps, are recorded by the path of the field,
+// while env variables may set single elements. Fields that have not been set by any source are not recorded.
+// It is safe for concurrent use.
+type Provenance struct {
+	mu      sync.RWMutex
+	sources map[string]Source
+}
+
+// WithProvenance records the sources of the fields into p on every successful load, see Provenance.
+// The sources of the previous load are replaced, so a Provenance should not be shared by concurrent loads.
+func WithProvenance(p *Provenance) Option {
+	return func(l *Loader) {
+		l.provenance = p
+	}
+}
+
+// Source returns the source of the field. If ok is false, the field has not been set by any source.
+// @fieldPath: The path of the field, e.g. Server.Port.
+func (p *Provenance) Source(fieldPath string) (src Source, ok bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	src, ok = p.sources[fieldPath]
+	return src, ok
+}
+
+// Sources returns the sources of all recorded fields by their path.
+func (p *Provenance) Sources() map[string]Source {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	sources := make(map[string]Source, len(p.sources))
+	for fieldPath, src := range p.sources {
+		sources[fieldPath] = src
+	}
+	return sources
+}
+
+// Explain returns a human-readable report with one line per recorded field in lexical order of the paths, e.g.
+//
+//	Name         file config.yml:1
+//	Server.Port  env CFG_SERVER_PORT
+func (p *Provenance) Explain() string {
+	sources := p.Sources()
+	paths := make([]string, 0, len(sources))
+	for fieldPath := range sources {
+		paths = append(paths, fieldPath)
+	}
+	sort.Strings(paths)
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	for _, fieldPath := range paths {
+		fmt.Fprintf(w, "%s\t%s\n", fieldPath, sources[fieldPath])
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// set replaces the recorded sources.
+func (p *Provenance) set(sources map[string]Source) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sources = sources
+}
+
+// recorder collects the sources of the fields during a single load. A nil recorder records nothing.
+type recorder struct {
+	sources map[string]Source
+	// file is the path of the config file that is currently parsed.
+	file string
+}
+
+// record sets the source of the field.
+func (r *recorder) record(fieldPath string, src Source) {
+	if r == nil {
+		return
+	}
+	r.sources[fieldPath] = src
+}
+
+// parsing sets the path of the config file that is parsed next.
+func (r *recorder) parsing(file string) {
+	if r == nil {
+		return
+	}
+	r.file = file
+}
+
+// recording returns a copy of the Loader with a new recorder if the Loader records the provenance,
+// otherwise the Loader itself. The copy holds the state of a single load, so the Loader can still be used concurrently.
+func (l *Loader) recording() *Loader {
+	if l.provenance == nil {
+		return l
+	}
+	lc := *l
+	lc.rec = &recorder{sources: map[string]Source{}}
+	return &lc
+}
+
+// publish passes the recorded sources to the Provenance of the Loader.
+func (l *Loader) publish() {
+	if l.rec == nil {
+		return
+	}
+	l.provenance.set(l.rec.sources)
+}
+
+// recordFile records the fields of the receiver that are set by the config file.
+// The keys are looked up in the parsed document, so fields that are explicitly set to the zero value are recorded as well.
+// Formats without document, e.g. formats registered by RegisterFormat, record all fields that are not zero
+// when the content is decoded on its own.
+// @doc: The document of the config file, nil if the format has no document.
+// @bts: The content of the config file.
+// @receiver: The receiver the config file has been parsed into.
+// @f: The format of the config file.
+func (l *Loader) recordFile(doc document, bts []byte, receiver interface{}, f Format) error {
+	if l.rec == nil {
+		return nil
+	}
+	val := reflect.ValueOf(receiver)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if doc != nil {
+		l.recordDocumentFields(doc, val.Elem(), "")
+		return nil
+	}
+	layer := reflect.New(val.Elem().Type())
+	err := unmarshal(bts, layer.Interface(), f)
+	if err != nil {
+		return err
+	}
+	l.recordSetFields(layer.Elem(), "")
+	return nil
+}
+
+// recordDocumentFields is the recursive part of recordFile for formats with document.
+// @doc: The document that describes val.
+// @val: The struct value to record the fields of.
+// @fieldPath: The path of val within the receiver.
+func (l *Loader) recordDocumentFields(doc document, val reflect.Value, fieldPath string) {
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if isPromotedField(doc, field) {
+			// the keys of promoted fields are keys of doc
+			f := val.Field(i)
+			for f.Kind() == reflect.Ptr && !f.IsNil() {
+				f = f.Elem()
+			}
+			if f.Kind() == reflect.Struct {
+				l.recordDocumentFields(doc, f, joinFieldPath(fieldPath, field.Name))
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		line, ok := doc.position(field)
+		if !ok {
+			continue
+		}
+		path := joinFieldPath(fieldPath, field.Name)
+		f := val.Field(i)
+		for f.Kind() == reflect.Ptr && !f.IsNil() {
+			f = f.Elem()
+		}
+		if f.Kind() == reflect.Struct && !isScalarType(f.Type()) {
+			if child, ok := doc.child(field); ok {
+				l.recordDocumentFields(child, f, path)
+				continue
+			}
+		}
+		l.rec.record(path, Source{Kind: SourceFile, Name: l.rec.file, Line: line})
+	}
+}
+
+// recordSetFields records all fields of the struct val that are not zero.
+// @val: The struct value to record the fields of.
+// @fieldPath: The path of val within the receiver.
+func (l *Loader) recordSetFields(val reflect.Value, fieldPath string) {
+	for i := 0; i < val.NumField(); i++ {
+		if !val.Field(i).CanSet() {
+			// unexported field
+			continue
+		}
+		path := joinFieldPath(fieldPath, val.Type().Field(i).Name)
+		f := val.Field(i)
+		for f.Kind() == reflect.Ptr && !f.IsNil() {
+			f = f.Elem()
+		}
+		switch {
+		case f.Kind() == reflect.Struct && !isScalarType(f.Type()):
+			l.recordSetFields(f, path)
+		case !isUnset(f):
+			l.rec.record(path, Source{Kind: SourceFile, Name: l.rec.file})
+		}
+	}
+}
+
+// isUnset reports whether v is not merged by mergeValues without document, i.e. it is zero or an empty slice or map.
+func isUnset(v reflect.Value) bool {
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Map {
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type provenanceExample struct {
+	Name     string
+	Age      int
+	Size     float64
+	Level    string `default:"info"`
+	Port     int    `default:"80"`
+	Hosts    []string
+	Labels   map[string]string
+	Children struct {
+		Name string
+		Age  int
+	}
+}
+
+func TestLoader_LoadProvenance(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	p := &Provenance{}
+	l := NewLoader(
+		WithProvenance(p),
+		WithFlagSet(fs),
+		WithEnviron(func() []string { return []string{"CFG_AGE=30", "CFG_LABELS_TEAM=core"} }),
+	)
+	got := &provenanceExample{}
+	err := l.RegisterFlags(got)
+	if err != nil {
+		t.Fatalf("Loader.RegisterFlags() error = %v", err)
+	}
+	err = fs.Parse([]string{"--children-age", "4"})
+	if err != nil {
+		t.Fatalf("FlagSet.Parse() error = %v", err)
+	}
+	err = l.Load(".file/simple.yml", got)
+	if err != nil {
+		t.Fatalf("Loader.Load() error = %v", err)
+	}
+
+	want := map[string]Source{
+		"Name":          {Kind: SourceFile, Name: ".file/simple.yml", Line: 1},
+		"Age":           {Kind: SourceEnv, Name: "CFG_AGE"},
+		"Size":          {Kind: SourceFile, Name: ".file/simple.yml", Line: 3},
+		"Level":         {Kind: SourceDefault},
+		"Port":          {Kind: SourceDefault},
+		"Hosts":         {Kind: SourceFile, Name: ".file/simple.yml", Line: 6},
+		"Labels[team]":  {Kind: SourceEnv, Name: "CFG_LABELS_TEAM"},
+		"Children.Name": {Kind: SourceFile, Name: ".file/simple.yml", Line: 10},
+		"Children.Age":  {Kind: SourceFlag, Name: "--children-age"},
+	}
+	if diff := cmp.Diff(want, p.Sources()); diff != "" {
+		t.Errorf("Provenance.Sources() mismatch (-want +got):\n%s", diff)
+	}
+	src, ok := p.Source("Age")
+	if !ok || src.String() != "env CFG_AGE" {
+		t.Errorf("Provenance.Source() = %v, %v, want env CFG_AGE", src, ok)
+	}
+
+	wantExplain := `Age            env CFG_AGE
+Children.Age   flag --children-age
+Children.Name  file .file/simple.yml:10
+Hosts          file .file/simple.yml:6
+Labels[team]   env CFG_LABELS_TEAM
+Level          default tag
+Name           file .file/simple.yml:1
+Port           default tag
+Size           file .file/simple.yml:3
+`
+	if diff := cmp.Diff(wantExplain, p.Explain()); diff != "" {
+		t.Errorf("Provenance.Explain() mismatch (-want +got):\n%s", diff)
+	}
+
+	// a failed load keeps the sources of the last successful load
+	err = l.Load(".file/missing.yml", &provenanceExample{})
+	if err == nil {
+		t.Fatalf("Loader.Load() expected error")
+	}
+	if diff := cmp.Diff(want, p.Sources()); diff != "" {
+		t.Errorf("Provenance.Sources() changed after failed load (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoader_LoadProvenanceFormats(t *testing.T) {
+	tests := []struct {
+		filePath string
+		want     map[string]Source
+	}{
+		{
+			filePath: ".file/simple.json",
+			want: map[string]Source{
+				"Name":         {Kind: SourceFile, Name: ".file/simple.json"},
+				"Children.Age": {Kind: SourceFile, Name: ".file/simple.json"},
+			},
+		},
+		{
+			filePath: ".file/simple.toml",
+			want: map[string]Source{
+				"Name":         {Kind: SourceFile, Name: ".file/simple.toml", Line: 1},
+				"Children.Age": {Kind: SourceFile, Name: ".file/simple.toml", Line: 10},
+			},
+		},
+		{
+			filePath: ".file/simple.hcl",
+			want: map[string]Source{
+				"Name":         {Kind: SourceFile, Name: ".file/simple.hcl", Line: 1},
+				"Children.Age": {Kind: SourceFile, Name: ".file/simple.hcl", Line: 9},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filePath, func(t *testing.T) {
+			p := &Provenance{}
+			l := NewLoader(WithProvenance(p), WithEnviron(func() []string { return nil }))
+			err := l.Load(tt.filePath, &ExampleConfigA{})
+			if err != nil {
+				t.Fatalf("Loader.Load() error = %v", err)
+			}
+			for fieldPath, want := range tt.want {
+				got, _ := p.Source(fieldPath)
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("Provenance.Source(%q) mismatch (-want +got):\n%s", fieldPath, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestLoader_LoadFilesProvenance(t *testing.T) {
+	type example struct {
+		Name     string
+		Age      int
+		Hosts    []string
+		Children struct {
+			Name string
+			Age  int
+		}
+	}
+	p := &Provenance{}
+	l := NewLoader(WithProvenance(p), WithEnviron(func() []string { return nil }))
+	err := l.LoadFiles(&example{}, ".file/simple.yml", ".file/override.yml")
+	if err != nil {
+		t.Fatalf("Loader.LoadFiles() error = %v", err)
+	}
+	want := map[string]Source{
+		"Name":          {Kind: SourceFile, Name: ".file/override.yml", Line: 1},
+		"Age":           {Kind: SourceFile, Name: ".file/simple.yml", Line: 2},
+		"Hosts":         {Kind: SourceFile, Name: ".file/override.yml", Line: 2},
+		"Children.Name": {Kind: SourceFile, Name: ".file/simple.yml", Line: 10},
+		"Children.Age":  {Kind: SourceFile, Name: ".file/override.yml", Line: 5},
+	}
+	if diff := cmp.Diff(want, p.Sources()); diff != "" {
+		t.Errorf("Provenance.Sources() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoader_LoadBytesProvenanceEmbedded(t *testing.T) {
+	type Base struct {
+		Name string
+	}
+	type example struct {
+		Base  `yaml:",inline"`
+		Extra string
+	}
+	tests := []struct {
+		name   string
+		data   string
+		format Format
+		want   map[string]Source
+	}{
+		{
+			name:   "yaml",
+			data:   "name: a\nextra: b\n",
+			format: YAML,
+			want: map[string]Source{
+				"Base.Name": {Kind: SourceFile, Line: 1},
+				"Extra":     {Kind: SourceFile, Line: 2},
+			},
+		},
+		{
+			name:   "json",
+			data:   `{"name": "a", "extra": "b"}`,
+			format: JSON,
+			want: map[string]Source{
+				"Base.Name": {Kind: SourceFile},
+				"Extra":     {Kind: SourceFile},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Provenance{}
+			l := NewLoader(WithProvenance(p), WithEnviron(func() []string { return nil }))
+			err := l.LoadBytes([]byte(tt.data), tt.format, &example{})
+			if err != nil {
+				t.Fatalf("Loader.LoadBytes() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, p.Sources()); diff != "" {
+				t.Errorf("Provenance.Sources() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadBytesProvenanceUnsupportedEnv(t *testing.T) {
+	type server struct {
+		Host string
+	}
+	type example struct {
+		Upstreams []server
+		Servers   map[string]server
+	}
+	p := &Provenance{}
+	l := NewLoader(
+		WithProvenance(p),
+		WithStrict(false),
+		WithEnviron(func() []string {
+			return []string{"CFG_UPSTREAMS=oops", "CFG_UPSTREAMS_0_HOST=a", "CFG_SERVERS=b=c"}
+		}),
+	)
+	got := &example{}
+	err := l.LoadBytes([]byte(`{}`), JSON, got)
+	if err != nil {
+		t.Fatalf("Loader.LoadBytes() error = %v", err)
+	}
+	if diff := cmp.Diff(&example{Upstreams: []server{{Host: "a"}}}, got); diff != "" {
+		t.Errorf("Loader.LoadBytes() mismatch (-want +got):\n%s", diff)
+	}
+	want := map[string]Source{"Upstreams[0].Host": {Kind: SourceEnv, Name: "CFG_UPSTREAMS_0_HOST"}}
+	if diff := cmp.Diff(want, p.Sources()); diff != "" {
+		t.Errorf("Provenance.Sources() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoader_LoadBytesProvenanceCustomFormat(t *testing.T) {
+	restoreRegistry(t)
+	type example struct {
+		Name string
+		Age  int
+	}
+	err := RegisterFormat("custom", []string{".custom"}, DecoderFunc(func(data []byte, receiver interface{}) error {
+		receiver.(*example).Name = string(data)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Provenance{}
+	l := NewLoader(WithProvenance(p), WithEnviron(func() []string { return nil }))
+	err = l.LoadBytes([]byte("Sam"), Format("custom"), &example{})
+	if err != nil {
+		t.Fatalf("Loader.LoadBytes() error = %v", err)
+	}
+	want := map[string]Source{"Name": {Kind: SourceFile}}
+	if diff := cmp.Diff(want, p.Sources()); diff != "" {
+		t.Errorf("Provenance.Sources() mismatch (-want +got):\n%s", diff)
+	}
+	if got := p.Explain(); got != "Name  config\n" {
+		t.Errorf("Provenance.Explain() = %q", got)
+	}
+}
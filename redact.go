@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// secretTag is the struct tag used to mark fields that must not be printed or exported, e.g. `secret:"true"`.
+const secretTag = "secret"
+
+// RedactedValue replaces the values of secret fields in redacted configs.
+const RedactedValue = "******"
+
+// redacted is the type of masked values. It differs from string, so that a view of a config with secret fields
+// never has the same type as the config itself.
+type redacted string
+
+var redactedType = reflect.TypeOf(redacted(""))
+
+// Redact returns a view of the receiver in which the values of all fields tagged with `secret:"true"` are replaced
+// by RedactedValue, including fields of nested structs, slices, maps and pointers. Secret fields that are not set
+// remain empty, so it is visible whether a secret has been configured. Secret slices and maps keep their length
+// and keys, only the elements are masked.
+// The view can be printed or passed to any encoder, time types are converted like Marshal does. Values without
+// secret fields may be shared with the receiver, so the view must not be modified. Nil is returned for a nil receiver.
+// @receiver: The config to redact, a struct or a pointer to a struct.
+func (l *Loader) Redact(receiver interface{}) interface{} {
+	view, err := l.exportView(receiver, "", true)
+	if err != nil {
+		return nil
+	}
+	return view
+}
+
+// RedactedString returns the receiver as single line JSON with all secret fields masked, see Redact.
+// It is meant for logging the effective config.
+// @receiver: The config to print, a struct or a pointer to a struct.
+func (l *Loader) RedactedString(receiver interface{}) string {
+	view, err := l.exportView(receiver, "", true)
+	if err != nil {
+		return fmt.Sprintf("%%!v(%v)", err)
+	}
+	bts, err := json.Marshal(view)
+	if err != nil {
+		return fmt.Sprintf("%%!v(%v)", err)
+	}
+	return string(bts)
+}
+
+// MarshalRedacted encodes the receiver in the format f with all secret fields masked, see Marshal and Redact.
+// @receiver: The config to encode, a struct or a pointer to a struct.
+// @f: The format to encode the config in. If it is empty, the format forced by WithFormat is used.
+func (l *Loader) MarshalRedacted(receiver interface{}, f Format) ([]byte, error) {
+	return l.marshal(receiver, f, true)
+}
+
+// isSecret reports whether the field is tagged as secret.
+func isSecret(field reflect.StructField) bool {
+	secret, err := strconv.ParseBool(field.Tag.Get(secretTag))
+	return err == nil && secret
+}
+
+// secretType returns the type of the masked values of a secret field of type t.
+// Pointers, slices, arrays and maps keep their structure, all other types are masked as a whole.
+func secretType(t reflect.Type) reflect.Type {
+	if isScalarType(t) {
+		return redactedType
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return reflect.PtrTo(secretType(t.Elem()))
+	case reflect.Slice:
+		return reflect.SliceOf(secretType(t.Elem()))
+	case reflect.Array:
+		return reflect.ArrayOf(t.Len(), secretType(t.Elem()))
+	case reflect.Map:
+		return reflect.MapOf(t.Key(), secretType(t.Elem()))
+	default:
+		return redactedType
+	}
+}
+
+// secretValue masks the value v of a secret field. Zero values are not masked.
+// @v: The value to mask.
+// @t: The type returned by secretType.
+func secretValue(v reflect.Value, t reflect.Type) reflect.Value {
+	out := reflect.New(t).Elem()
+	if t == redactedType {
+		if !v.IsZero() {
+			out.SetString(RedactedValue)
+		}
+		return out
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			elem := reflect.New(t.Elem())
+			elem.Elem().Set(secretValue(v.Elem(), t.Elem()))
+			out.Set(elem)
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			out.Set(reflect.MakeSlice(t, v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				out.Index(i).Set(secretValue(v.Index(i), t.Elem()))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(secretValue(v.Index(i), t.Elem()))
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			out.Set(reflect.MakeMapWithSize(t, v.Len()))
+			iter := v.MapRange()
+			for iter.Next() {
+				out.SetMapIndex(iter.Key(), secretValue(iter.Value(), t.Elem()))
+			}
+		}
+	}
+	return out
+}
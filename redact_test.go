@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type redactDatabase struct {
+	User     string `json:"user"`
+	Password string `json:"password" secret:"true"`
+}
+
+type redactExample struct {
+	Name      string                    `json:"name"`
+	Token     string                    `json:"token" secret:"true"`
+	Empty     string                    `json:"empty" secret:"true"`
+	Pin       *int                      `json:"pin" secret:"true"`
+	Keys      []string                  `json:"keys" secret:"true"`
+	Headers   map[string]string         `json:"headers" secret:"true"`
+	Timeout   time.Duration             `json:"timeout"`
+	Database  redactDatabase            `json:"database"`
+	Replica   *redactDatabase           `json:"replica"`
+	Databases []redactDatabase          `json:"databases"`
+	Named     map[string]redactDatabase `json:"named"`
+	Public    []string                  `json:"public" secret:"false"`
+}
+
+func newRedactExample() *redactExample {
+	pin := 1234
+	return &redactExample{
+		Name:      "app",
+		Token:     "t0k3n",
+		Pin:       &pin,
+		Keys:      []string{"k1", "k2"},
+		Headers:   map[string]string{"Authorization": "Bearer abc"},
+		Timeout:   time.Minute,
+		Database:  redactDatabase{User: "sam", Password: "db-pass"},
+		Replica:   &redactDatabase{User: "replica", Password: "replica-pass"},
+		Databases: []redactDatabase{{User: "a", Password: "a-pass"}},
+		Named:     map[string]redactDatabase{"b": {User: "b", Password: "b-pass"}},
+		Public:    []string{"p"},
+	}
+}
+
+func TestLoader_RedactedString(t *testing.T) {
+	cfg := newRedactExample()
+	got := NewLoader().RedactedString(cfg)
+	want := `{"name":"app","token":"******","empty":"","pin":"******","keys":["******","******"],` +
+		`"headers":{"Authorization":"******"},"timeout":"1m0s","database":{"user":"sam","password":"******"},` +
+		`"replica":{"user":"replica","password":"******"},"databases":[{"user":"a","password":"******"}],` +
+		`"named":{"b":{"user":"b","password":"******"}},"public":["p"]}`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Loader.RedactedString() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(newRedactExample(), cfg); diff != "" {
+		t.Errorf("Loader.RedactedString() modified the receiver (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoader_RedactedStringEmbedded(t *testing.T) {
+	type Base struct {
+		Password string `secret:"true"`
+		Name     string
+	}
+	type Timeouts struct {
+		Read time.Duration
+	}
+	type example struct {
+		Base
+		*Timeouts
+		Name  string
+		Token string `secret:"true"`
+	}
+	tests := []struct {
+		name     string
+		receiver interface{}
+		want     string
+	}{
+		{
+			name:     "promoted fields",
+			receiver: &example{Base: Base{Password: "hunter2", Name: "base"}, Timeouts: &Timeouts{Read: time.Second}, Name: "x", Token: "tok"},
+			want:     `{"Password":"******","Read":"1s","Name":"x","Token":"******"}`,
+		},
+		{
+			name:     "nil embedded pointer",
+			receiver: &example{Base: Base{Password: "hunter2"}},
+			want:     `{"Password":"******","Read":"0s","Name":"","Token":""}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewLoader().RedactedString(tt.receiver)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.RedactedString() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_Redact(t *testing.T) {
+	cfg := newRedactExample()
+	got := fmt.Sprintf("%+v", NewLoader().Redact(*cfg))
+	for _, secret := range []string{"t0k3n", "1234", "k1", "Bearer", "db-pass", "replica-pass", "a-pass", "b-pass"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("Loader.Redact() contains secret %q: %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, "Token:"+RedactedValue) {
+		t.Errorf("Loader.Redact() does not mask the token: %s", got)
+	}
+	if NewLoader().Redact(nil) != nil {
+		t.Errorf("Loader.Redact() expected nil for nil receiver")
+	}
+}
+
+func TestLoader_MarshalRedacted(t *testing.T) {
+	type example struct {
+		User     string
+		Password string `secret:"true"`
+	}
+	tests := []struct {
+		name     string
+		receiver interface{}
+		f        Format
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "yaml",
+			receiver: &example{User: "sam", Password: "secret"},
+			f:        YAML,
+			want:     "user: sam\npassword: '******'\n",
+		},
+		{
+			name:     "toml",
+			receiver: &example{User: "sam", Password: "secret"},
+			f:        TOML,
+			want:     "Password = \"******\"\nUser = \"sam\"\n",
+		},
+		{
+			name:     "unsupported format",
+			receiver: &example{},
+			f:        Format("ini"),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLoader().MarshalRedacted(tt.receiver, tt.f)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Loader.MarshalRedacted() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("Loader.MarshalRedacted() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrConfigNotFound is returned if no config file has been found in the search paths.
+var ErrConfigNotFound = errors.New("config file not found")
+
+// SearchMode defines which of the config files found in the search paths are loaded.
+type SearchMode int
+
+const (
+	// SearchFirst loads only the file with the highest precedence.
+	SearchFirst SearchMode = iota
+	// SearchAll loads all files found and merges them, files with a higher precedence override the others.
+	SearchAll
+)
+
+// WithSearchMode defines which of the config files found in the search paths are loaded. The default is SearchFirst.
+func WithSearchMode(mode SearchMode) Option {
+	return func(l *Loader) {
+		l.searchMode = mode
+	}
+}
+
+// SearchPaths returns the directories that are searched for the config files of the application app,
+// ordered from the highest to the lowest precedence:
+// ./, $XDG_CONFIG_HOME/<app>/ (or $HOME/.config/<app>/ if XDG_CONFIG_HOME is not set), $HOME/.<app>/ and /etc/<app>/.
+// Directories that depend on unset env variables are omitted.
+// @app: The name of the application.
+func (l *Loader) SearchPaths(app string) []string {
+	env := l.environVars()
+	home := env["HOME"]
+	dirs := []string{"."}
+	switch {
+	case env["XDG_CONFIG_HOME"] != "":
+		dirs = append(dirs, filepath.Join(env["XDG_CONFIG_HOME"], app))
+	case home != "":
+		dirs = append(dirs, filepath.Join(home, ".config", app))
+	}
+	if home != "" {
+		dirs = append(dirs, filepath.Join(home, "."+app))
+	}
+	return append(dirs, filepath.Join("/etc", app))
+}
+
+// Search returns the config files of the application app that exist in the search paths,
+// ordered from the highest to the lowest precedence. Every extension of the accepted formats is probed,
+// unless name already has a known extension.
+// @app: The name of the application, see SearchPaths.
+// @name: The base name of the config file, e.g. "config".
+func (l *Loader) Search(app, name string) ([]string, error) {
+	exts := []string{""}
+	if detectFormat(name) == "" {
+		exts = exts[:0]
+		for _, ext := range registeredExtensions() {
+			if l.supportsFormat(detectFormat(ext)) {
+				exts = append(exts, ext)
+			}
+		}
+	}
+	found := []string{}
+	for _, dir := range l.SearchPaths(app) {
+		for _, ext := range exts {
+			filePath := filepath.Join(dir, name+ext)
+			stat, err := os.Stat(filePath)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if stat.Mode().IsRegular() {
+				found = append(found, filePath)
+			}
+		}
+	}
+	return found, nil
+}
+
+// LoadSearch searches the config files of the application app, see Search, loads them into the receiver according
+// to the search mode and enriches the config with the value from env variables. Finally the receiver is validated, see Validate.
+// It returns the files that have been loaded in the order they have been merged.
+// If no file has been found, ErrConfigNotFound is returned.
+// @app: The name of the application, see SearchPaths.
+// @name: The base name of the config file, e.g. "config".
+// @receiver: The receiver to parse the config files into.
+func (l *Loader) LoadSearch(app, name string, receiver interface{}) ([]string, error) {
+	found, err := l.Search(app, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%w: %s in %q", ErrConfigNotFound, name, l.SearchPaths(app))
+	}
+	used := found[:1]
+	if l.searchMode == SearchAll {
+		// files with the lowest precedence are merged first
+		used = make([]string, 0, len(found))
+		for i := len(found) - 1; i >= 0; i-- {
+			used = append(used, found[i])
+		}
+	}
+	return used, l.LoadFiles(receiver, used...)
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoader_SearchPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		environ []string
+		want    []string
+	}{
+		{
+			name:    "xdg config home",
+			environ: []string{"HOME=/home/sam", "XDG_CONFIG_HOME=/xdg"},
+			want:    []string{".", "/xdg/myapp", "/home/sam/.myapp", "/etc/myapp"},
+		},
+		{
+			name:    "xdg fallback",
+			environ: []string{"HOME=/home/sam"},
+			want:    []string{".", "/home/sam/.config/myapp", "/home/sam/.myapp", "/etc/myapp"},
+		},
+		{
+			name: "no home",
+			want: []string{".", "/etc/myapp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLoader(WithEnviron(func() []string { return tt.environ }))
+			got := l.SearchPaths("myapp")
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.SearchPaths() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadSearch(t *testing.T) {
+	type example struct {
+		Name  string
+		Port  int
+		Debug bool
+	}
+	home := t.TempDir()
+	xdg := t.TempDir()
+	writeFile := func(filePath, content string) {
+		err := os.MkdirAll(filepath.Dir(filePath), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(filePath, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(xdg, "go-config-test", "config.yml"), "port: 8080\n")
+	writeFile(filepath.Join(home, ".go-config-test", "config.json"), `{"name": "home", "port": 80, "debug": true}`)
+	environ := func() []string { return []string{"HOME=" + home, "XDG_CONFIG_HOME=" + xdg} }
+
+	tests := []struct {
+		name     string
+		fileName string
+		opts     []Option
+		want     *example
+		wantUsed []string
+		wantErr  error
+	}{
+		{
+			name:     "first match",
+			fileName: "config",
+			want:     &example{Port: 8080},
+			wantUsed: []string{filepath.Join(xdg, "go-config-test", "config.yml")},
+		},
+		{
+			name:     "layer all",
+			fileName: "config",
+			opts:     []Option{WithSearchMode(SearchAll)},
+			want:     &example{Name: "home", Port: 8080, Debug: true},
+			wantUsed: []string{
+				filepath.Join(home, ".go-config-test", "config.json"),
+				filepath.Join(xdg, "go-config-test", "config.yml"),
+			},
+		},
+		{
+			name:     "restricted formats",
+			fileName: "config",
+			opts:     []Option{WithFormats(JSON)},
+			want:     &example{Name: "home", Port: 80, Debug: true},
+			wantUsed: []string{filepath.Join(home, ".go-config-test", "config.json")},
+		},
+		{
+			name:     "name with extension",
+			fileName: "config.json",
+			want:     &example{Name: "home", Port: 80, Debug: true},
+			wantUsed: []string{filepath.Join(home, ".go-config-test", "config.json")},
+		},
+		{
+			name:     "not found",
+			fileName: "missing",
+			wantErr:  ErrConfigNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &example{}
+			l := NewLoader(append([]Option{WithEnviron(environ)}, tt.opts...)...)
+			used, err := l.LoadSearch("go-config-test", tt.fileName, got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Loader.LoadSearch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.wantUsed, used); diff != "" {
+				t.Errorf("Loader.LoadSearch() used mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.LoadSearch() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/hcl"
+)
+
+// FormatDetection defines how the format of a config is detected.
+type FormatDetection int
+
+const (
+	// DetectByExtension detects the format by the file extension only.
+	DetectByExtension FormatDetection = iota
+	// DetectByExtensionOrContent detects the format by the file extension
+	// and inspects the content if the extension is missing or unknown.
+	DetectByExtensionOrContent
+	// DetectByContent always inspects the content and only uses the file extension
+	// to resolve content that is valid in multiple formats.
+	DetectByContent
+)
+
+var (
+	// tomlTableRegex matches TOML table headers, e.g. [server] or [[upstreams]].
+	tomlTableRegex = regexp.MustCompile(`^\[\[?\s*[\w."'\- ]+\s*\]\]?$`)
+	// hclBlockRegex matches the opening line of HCL blocks, e.g. server {  or  service "api" {.
+	hclBlockRegex = regexp.MustCompile(`^[A-Za-z_][\w\-]*(\s+"[^"]*")*\s*\{$`)
+	// yamlKeyRegex matches YAML mapping keys and sequence entries, e.g. name: Sam  or  - localhost.
+	yamlKeyRegex = regexp.MustCompile(`^([\w\-."']+\s*:(\s|$)|-\s)`)
+)
+
+// resolveFormat returns the format of the content bts according to the format detection of the Loader.
+// @hint: The format detected by the file extension or passed by the caller, may be empty.
+// @bts: The content of the config.
+func (l *Loader) resolveFormat(hint Format, bts []byte) (Format, error) {
+	if l.format != "" {
+		return l.format, nil
+	}
+	switch l.formatDetection {
+	case DetectByExtensionOrContent:
+		if _, ok := lookupFormat(hint); ok {
+			return hint, nil
+		}
+		return sniffFormat(bts, hint)
+	case DetectByContent:
+		return sniffFormat(bts, hint)
+	default:
+		return hint, nil
+	}
+}
+
+// sniffFormat detects the built-in format of the content bts.
+// The markers of the formats are checked first. If they are not conclusive, the content is parsed with every
+// built-in format. If the content is valid in multiple formats, hint is used if it is one of them.
+// @bts: The content of the config.
+// @hint: The preferred format if the content is ambiguous, may be empty.
+func sniffFormat(bts []byte, hint Format) (Format, error) {
+	valid := validFormats(bts, sniffCandidates(bts))
+	if len(valid) == 0 {
+		// the markers are misleading, e.g. a YAML key within a TOML string
+		valid = validFormats(bts, []Format{JSON, YAML, TOML, HCL})
+	}
+	if len(valid) > 1 && valid[0] == JSON {
+		// every JSON object is a valid YAML document as well
+		valid = valid[:1]
+	}
+	switch len(valid) {
+	case 0:
+		return "", fmt.Errorf("unable to detect the format of the content")
+	case 1:
+		return valid[0], nil
+	}
+	for _, f := range valid {
+		if f == hint {
+			return f, nil
+		}
+	}
+	names := make([]string, 0, len(valid))
+	for _, f := range valid {
+		names = append(names, string(f))
+	}
+	return "", fmt.Errorf("ambiguous format, the content is valid %s, use WithFormat to choose one", strings.Join(names, " and "))
+}
+
+// validFormats returns the formats of candidates that are able to parse the content bts.
+// Formats that have been replaced by RegisterFormat can not be checked and are considered valid.
+func validFormats(bts []byte, candidates []Format) []Format {
+	valid := []Format{}
+	for _, f := range candidates {
+		rf, ok := lookupFormat(f)
+		if !ok {
+			continue
+		}
+		if rf.parse != nil {
+			doc, err := rf.parse(bts)
+			if err != nil || doc == nil {
+				continue
+			}
+			if hd, ok := doc.(*hclDocument); ok && !hclEntriesHaveValues(hd.ast.Entries) {
+				// the HCL parser accepts attributes without values, e.g. plain text
+				continue
+			}
+		}
+		valid = append(valid, f)
+	}
+	return valid
+}
+
+// sniffCandidates returns the formats indicated by the markers of the content bts.
+// An empty result means that no marker has been found.
+func sniffCandidates(bts []byte) []Format {
+	content := bytes.TrimSpace(bytes.TrimPrefix(bts, []byte("\xef\xbb\xbf")))
+	if len(content) == 0 {
+		return nil
+	}
+	if content[0] == '{' && json.Valid(content) {
+		return []Format{JSON}
+	}
+
+	var first string
+	var yamlKeys, tomlTables, hclBlocks bool
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if first == "" {
+			first = line
+		}
+		switch {
+		case tomlTableRegex.MatchString(line):
+			tomlTables = true
+		case hclBlockRegex.MatchString(line):
+			hclBlocks = true
+		case yamlKeyRegex.MatchString(line):
+			yamlKeys = true
+		}
+	}
+	if first == "---" || strings.HasPrefix(first, "--- ") || strings.HasPrefix(first, "%YAML") {
+		return []Format{YAML}
+	}
+
+	candidates := []Format{}
+	if yamlKeys && !tomlTables && !hclBlocks {
+		candidates = append(candidates, YAML)
+	}
+	if tomlTables {
+		candidates = append(candidates, TOML)
+	}
+	if hclBlocks {
+		candidates = append(candidates, HCL)
+	}
+	return candidates
+}
+
+// hclEntriesHaveValues reports whether all attributes of the entries and their nested blocks have a value.
+func hclEntriesHaveValues(entries []*hcl.Entry) bool {
+	for _, entry := range entries {
+		if entry.Attribute != nil && entry.Attribute.Value == nil {
+			return false
+		}
+		if entry.Block != nil && !hclEntriesHaveValues(entry.Block.Body) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_sniffFormat(t *testing.T) {
+	type args struct {
+		content string
+		hint    Format
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    Format
+		wantErr bool
+	}{
+		{
+			name: "json object",
+			args: args{
+				content: `{"name": "Simple Sam", "hosts": ["localhost"]}`,
+			},
+			want: JSON,
+		},
+		{
+			name: "json object with yaml hint",
+			args: args{
+				content: `{"name": "Simple Sam"}`,
+				hint:    YAML,
+			},
+			want: JSON,
+		},
+		{
+			name: "yaml document marker",
+			args: args{
+				content: "---\nname: Simple Sam\n",
+			},
+			want: YAML,
+		},
+		{
+			name: "yaml keys",
+			args: args{
+				content: "# comment\nname: Simple Sam\nhosts:\n  - localhost\n",
+			},
+			want: YAML,
+		},
+		{
+			name: "toml tables",
+			args: args{
+				content: "name = \"Simple Sam\"\n\n[children]\nname = \"Chris Sam\"\n",
+			},
+			want: TOML,
+		},
+		{
+			name: "hcl blocks",
+			args: args{
+				content: "name = \"Simple Sam\"\n\nchildren \"Chris Sam\" {\n  age = 3\n}\n",
+			},
+			want: HCL,
+		},
+		{
+			name: "ambiguous key value pairs",
+			args: args{
+				content: "name = \"Simple Sam\"\nage = 25\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "ambiguous key value pairs with hint",
+			args: args{
+				content: "name = \"Simple Sam\"\nage = 25\n",
+				hint:    HCL,
+			},
+			want: HCL,
+		},
+		{
+			name: "unknown content",
+			args: args{
+				content: "just some text",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sniffFormat([]byte(tt.args.content), tt.args.hint)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sniffFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("sniffFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sniffFormatFiles(t *testing.T) {
+	for _, filePath := range []string{".file/simple.yml", ".file/simple.json", ".file/simple.toml", ".file/simple.hcl"} {
+		t.Run(filePath, func(t *testing.T) {
+			bts, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := sniffFormat(bts, "")
+			if err != nil {
+				t.Fatalf("sniffFormat() error = %v", err)
+			}
+			if want := detectFormat(filePath); got != want {
+				t.Errorf("sniffFormat() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadFormatDetection(t *testing.T) {
+	type example struct {
+		Name string `hcl:"name"`
+		Age  int    `hcl:"age"`
+	}
+	type args struct {
+		fileName string
+		content  string
+		opts     []Option
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *example
+		wantErr bool
+	}{
+		{
+			name: "missing extension by extension",
+			args: args{
+				fileName: "config",
+				content:  "name: Simple Sam\nage: 25\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing extension by extension or content",
+			args: args{
+				fileName: "config",
+				content:  "name: Simple Sam\nage: 25\n",
+				opts:     []Option{WithFormatDetection(DetectByExtensionOrContent)},
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+		{
+			name: "wrong extension by extension or content",
+			args: args{
+				fileName: "config.yml",
+				content:  "[server]\nport = 8080\n",
+				opts:     []Option{WithFormatDetection(DetectByExtensionOrContent)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong extension by content",
+			args: args{
+				fileName: "config.yml",
+				content:  `{"name": "Simple Sam", "age": 25}`,
+				opts:     []Option{WithFormatDetection(DetectByContent)},
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+		{
+			name: "ambiguous content resolved by extension",
+			args: args{
+				fileName: "config.hcl",
+				content:  "name = \"Simple Sam\"\nage = 25\n",
+				opts:     []Option{WithFormatDetection(DetectByContent)},
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+		{
+			name: "ambiguous content",
+			args: args{
+				fileName: "config",
+				content:  "name = \"Simple Sam\"\nage = 25\n",
+				opts:     []Option{WithFormatDetection(DetectByContent)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ambiguous content with explicit format",
+			args: args{
+				fileName: "config",
+				content:  "name = \"Simple Sam\"\nage = 25\n",
+				opts:     []Option{WithFormatDetection(DetectByContent), WithFormat(TOML)},
+			},
+			want: &example{Name: "Simple Sam", Age: 25},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.args.fileName)
+			err := ioutil.WriteFile(filePath, []byte(tt.args.content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := &example{}
+			opts := append([]Option{WithEnviron(func() []string { return nil })}, tt.args.opts...)
+			err = NewLoader(opts...).Load(filePath, got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Loader.Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.Load() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,893 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/hcl"
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+// TimeLayouts are the layouts that are tried in the given order to parse time.Time values
+// from env variables, default tags and config files.
+//
+// Deprecated: use the WithTimeLayouts option of NewLoader.
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	locationType = reflect.TypeOf(time.Location{})
+)
+
+// isTimeType reports whether t is time.Duration, time.Time or time.Location.
+// These types are always set as a whole from a single string, even though time.Duration is an int64
+// and time.Time and time.Location are structs.
+func isTimeType(t reflect.Type) bool {
+	return t == durationType || t == timeType || t == locationType
+}
+
+// setTimeFromString parses raw according to the time type of v and sets the result.
+// Durations use the syntax of time.ParseDuration, times are parsed with the time layouts of the Loader and locations with time.LoadLocation.
+// @v: The settable value to set. Its type must satisfy isTimeType.
+// @raw: The string representation of the value.
+func (l *Loader) setTimeFromString(v reflect.Value, raw string) error {
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case timeType:
+		var err error
+		for _, layout := range l.timeLayouts {
+			var t time.Time
+			t, err = time.Parse(layout, raw)
+			if err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("time %q does not match any of the layouts %q: %w", raw, l.timeLayouts, err)
+	case locationType:
+		loc, err := time.LoadLocation(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(loc).Elem())
+	}
+	return nil
+}
+
+// setTimeFromRaw sets the time type v from a value that has been extracted from a config file.
+// Strings are parsed with setTimeFromString, integers are interpreted as nanoseconds for durations
+// and native date times of the format are used as is.
+// @v: The settable value to set. Its type, or the type it points to, must satisfy isTimeType.
+// @raw: The extracted value.
+func (l *Loader) setTimeFromRaw(v reflect.Value, raw interface{}) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	switch r := raw.(type) {
+	case string:
+		return l.setTimeFromString(v, r)
+	case time.Time:
+		if v.Type() == timeType {
+			v.Set(reflect.ValueOf(r))
+			return nil
+		}
+	case int64:
+		if v.Type() == durationType {
+			v.SetInt(r)
+			return nil
+		}
+	}
+	return l.setTimeFromString(v, fmt.Sprint(raw))
+}
+
+// extractedValue is a value that has been removed from a config file before decoding.
+type extractedValue struct {
+	// steps are the steps from the receiver to the value.
+	steps []step
+	// fieldPath is the path of the field, used for error reporting.
+	fieldPath string
+	// raw is the value as it was stored in the config file.
+	raw interface{}
+}
+
+// step is a step from a value to a nested value. Struct fields are addressed by their index,
+// the elements of slices, arrays and maps by their key, i.e. the index or map key as written in the config file.
+type step struct {
+	// field is the index of the struct field, it is -1 for elements.
+	field int
+	// key is the key of the element.
+	key string
+}
+
+// document is a parsed config file that allows to remove values before it is decoded into the receiver.
+// It is used to handle time types consistently across all formats, since the decoders behave differently,
+// e.g. encoding/json does not support duration strings and go-toml does not support time strings.
+type document interface {
+	// extract takes the scalar value of the field out of the document and returns it.
+	// The value is either removed or replaced by a placeholder the decoder accepts.
+	extract(field reflect.StructField) (interface{}, bool)
+	// child returns the nested document of the field.
+	child(field reflect.StructField) (document, bool)
+	// position reports whether the document contains the field and returns the line of its key.
+	// The line is 0 if the format does not provide lines.
+	position(field reflect.StructField) (int, bool)
+	// elements returns the elements of the slice, array or map of the field.
+	// Scalar elements of time types are replaced by placeholders the decoder accepts.
+	elements(field reflect.StructField) (*elementsDocument, bool)
+	// decode decodes the document into the receiver.
+	decode(receiver interface{}) error
+}
+
+// decodeWithTimeValues decodes bts of the format f into the receiver.
+// All time types are removed from the document before decoding and are parsed afterwards with the same rules
+// as env variables, so that every format accepts the same syntax.
+// @bts: The content of the config file.
+// @receiver: The receiver to decode the config file into.
+// @f: The format of the config file.
+func (l *Loader) decodeWithTimeValues(bts []byte, receiver interface{}, f Format) error {
+	val := reflect.ValueOf(receiver)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return unmarshal(bts, receiver, f)
+	}
+	doc, err := parseDocument(bts, f)
+	if err != nil || doc == nil {
+		// let the decoder report the error
+		return unmarshal(bts, receiver, f)
+	}
+	extracted := []extractedValue{}
+	extractTimeValues(val.Elem().Type(), doc, nil, "", &extracted)
+	if len(extracted) == 0 {
+		return unmarshal(bts, receiver, f)
+	}
+	err = doc.decode(receiver)
+	if err != nil {
+		return err
+	}
+	for _, ev := range extracted {
+		err := l.setExtractedValue(val.Elem(), ev.steps, ev.raw)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", ev.fieldPath, err)
+		}
+	}
+	return nil
+}
+
+// setExtractedValue sets the time type that the steps lead to from the extracted value raw.
+// Pointers, maps and slices on the way are created, since the decoder skips the values that have been extracted.
+// @v: The settable value the steps start at.
+// @steps: The steps from v to the time type, see extractedValue.
+// @raw: The extracted value.
+func (l *Loader) setExtractedValue(v reflect.Value, steps []step, raw interface{}) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if len(steps) == 0 {
+		return l.setTimeFromRaw(v, raw)
+	}
+	s := steps[0]
+	if s.field >= 0 {
+		return l.setExtractedValue(v.Field(s.field), steps[1:], raw)
+	}
+	if v.Kind() == reflect.Map {
+		key := reflect.New(v.Type().Key()).Elem()
+		err := l.setValueFromString(key, s.key)
+		if err != nil {
+			return fmt.Errorf("invalid key %q: %w", s.key, err)
+		}
+		// map elements are not addressable, so the element is copied and set afterwards
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		} else if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		err = l.setExtractedValue(elem, steps[1:], raw)
+		if err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	}
+	i, err := strconv.Atoi(s.key)
+	if err != nil {
+		return err
+	}
+	if v.Kind() == reflect.Slice && i >= v.Len() {
+		grown := reflect.MakeSlice(v.Type(), i+1, i+1)
+		reflect.Copy(grown, v)
+		v.Set(grown)
+	}
+	if i >= v.Len() {
+		return fmt.Errorf("index out of range, the array has a length of %d", v.Len())
+	}
+	return l.setExtractedValue(v.Index(i), steps[1:], raw)
+}
+
+// extractTimeValues walks through the struct type t and removes the values of all time types from the document,
+// including the elements of slices, arrays and maps and the fields of embedded structs.
+// @t: The struct type that describes the document.
+// @doc: The document to extract the values from.
+// @steps: The steps from the receiver to t.
+// @fieldPath: The path of t within the receiver.
+// @out: The extracted values.
+func extractTimeValues(t reflect.Type, doc document, steps []step, fieldPath string, out *[]extractedValue) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		fieldSteps := append(append([]step{}, steps...), step{field: i})
+		path := joinFieldPath(fieldPath, field.Name)
+		if isPromotedField(doc, field) {
+			if field.PkgPath != "" && field.Type.Kind() == reflect.Ptr {
+				// unexported embedded pointers can not be allocated
+				continue
+			}
+			extractTimeValues(ft, doc, fieldSteps, path, out)
+			continue
+		}
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		extractValueTimeValues(ft, doc, field, fieldSteps, path, out)
+	}
+}
+
+// extractValueTimeValues removes the time types of the value of the field from the document.
+// @t: The type of the value, without pointers.
+// @doc: The document that contains the value.
+// @field: The field of the value, or a field with the key of the element as name for elements.
+// @steps: The steps from the receiver to the value.
+// @fieldPath: The path of the value within the receiver.
+// @out: The extracted values.
+func extractValueTimeValues(t reflect.Type, doc document, field reflect.StructField, steps []step, fieldPath string,
+	out *[]extractedValue) {
+	switch {
+	case isTimeType(t):
+		raw, ok := doc.extract(field)
+		if ok {
+			*out = append(*out, extractedValue{steps: steps, fieldPath: fieldPath, raw: raw})
+		}
+	case t.Kind() == reflect.Struct && !isScalarType(t):
+		child, ok := doc.child(field)
+		if ok {
+			extractTimeValues(t, child, steps, fieldPath, out)
+		}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map:
+		if !containsTimeType(t.Elem(), map[reflect.Type]bool{}) {
+			return
+		}
+		elems, ok := doc.elements(field)
+		if !ok {
+			return
+		}
+		et := t.Elem()
+		for et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+		for _, key := range elems.keys {
+			elemSteps := append(append([]step{}, steps...), step{field: -1, key: key})
+			elemField := reflect.StructField{Name: key, Type: t.Elem()}
+			extractValueTimeValues(et, elems, elemField, elemSteps, fmt.Sprintf("%s[%s]", fieldPath, key), out)
+		}
+	}
+}
+
+// containsTimeType reports whether t is a time type or a struct, slice, array or map that contains one.
+// @t: The type to check.
+// @visited: The struct types that have already been checked, to stop on recursive types.
+func containsTimeType(t reflect.Type, visited map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isTimeType(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return containsTimeType(t.Elem(), visited)
+	case reflect.Struct:
+		if visited[t] || isScalarType(t) {
+			return false
+		}
+		visited[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if (field.PkgPath == "" || field.Anonymous) && containsTimeType(field.Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isTimeElementType reports whether the elements of the slice, array or map of the field are time types,
+// i.e. whether scalar elements of the field are extracted.
+func isTimeElementType(field reflect.StructField) bool {
+	t, ok := elementType(field)
+	return ok && isTimeType(t)
+}
+
+// isDurationElementType reports whether the elements of the slice, array or map of the field are durations.
+func isDurationElementType(field reflect.StructField) bool {
+	t, ok := elementType(field)
+	return ok && t == durationType
+}
+
+// elementType returns the element type of the slice, array or map of the field without pointers.
+func elementType(field reflect.StructField) (reflect.Type, bool) {
+	t := field.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+	default:
+		return nil, false
+	}
+	t = t.Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, true
+}
+
+// parseDocument parses bts into a document of the format f.
+// A nil document is returned if the content can not be represented as document, e.g. a JSON array.
+func parseDocument(bts []byte, f Format) (document, error) {
+	rf, ok := lookupFormat(f)
+	if !ok || rf.parse == nil {
+		return nil, nil
+	}
+	return rf.parse(bts)
+}
+
+// fieldMatchesKey reports whether the key of a config file belongs to the field.
+// The name is taken from the tag of the format, e.g. `json:"name"`, and defaults to the field name.
+// Keys are compared case insensitive, since the decoders differ in this regard.
+func fieldMatchesKey(field reflect.StructField, tagName, key string) bool {
+	name := strings.Split(field.Tag.Get(tagName), ",")[0]
+	if name == "-" {
+		return false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return strings.EqualFold(name, key)
+}
+
+// isPromotedField reports whether the keys of the struct field are keys of doc, the document that contains the field,
+// instead of being nested below the key of the field. This applies to embedded structs, whose fields the decoders
+// promote unless the document has a key for the embedded struct itself, and to fields tagged `yaml:",inline"`.
+// @doc: The document that contains the field.
+// @field: The struct field to check.
+func isPromotedField(doc document, field reflect.StructField) bool {
+	ft := field.Type
+	for ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	if ft.Kind() != reflect.Struct || isScalarType(ft) {
+		return false
+	}
+	if hasTagOption(field, "yaml", "inline") {
+		return true
+	}
+	if !field.Anonymous {
+		return false
+	}
+	_, ok := doc.position(field)
+	return !ok
+}
+
+// hasTagOption reports whether the tag tagName of the field contains the option, e.g. `hcl:"name,label"`.
+func hasTagOption(field reflect.StructField, tagName, option string) bool {
+	for _, o := range strings.Split(field.Tag.Get(tagName), ",")[1:] {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// elementsDocument is a document of the elements of a slice, array or map. Its keys are the indexes of the elements
+// or the keys of the map, they are matched against the name of the field.
+type elementsDocument struct {
+	// keys are the keys of the elements in the order of the config file.
+	keys     []string
+	values   map[string]interface{}
+	children map[string]document
+	lines    map[string]int
+}
+
+func newElementsDocument() *elementsDocument {
+	return &elementsDocument{
+		values:   map[string]interface{}{},
+		children: map[string]document{},
+		lines:    map[string]int{},
+	}
+}
+
+// add adds the element with the key, which is either the extracted scalar value or the nested document child.
+func (d *elementsDocument) add(key string, line int, value interface{}, child document) {
+	d.keys = append(d.keys, key)
+	d.lines[key] = line
+	if child != nil {
+		d.children[key] = child
+		return
+	}
+	d.values[key] = value
+}
+
+func (d *elementsDocument) extract(field reflect.StructField) (interface{}, bool) {
+	raw, ok := d.values[field.Name]
+	delete(d.values, field.Name)
+	return raw, ok
+}
+
+func (d *elementsDocument) child(field reflect.StructField) (document, bool) {
+	child, ok := d.children[field.Name]
+	return child, ok
+}
+
+func (d *elementsDocument) position(field reflect.StructField) (int, bool) {
+	line, ok := d.lines[field.Name]
+	return line, ok
+}
+
+func (d *elementsDocument) elements(field reflect.StructField) (*elementsDocument, bool) {
+	// nested slices and maps are left to the decoder
+	return nil, false
+}
+
+func (d *elementsDocument) decode(receiver interface{}) error {
+	return fmt.Errorf("the elements of a config file can not be decoded")
+}
+
+type yamlDocument struct {
+	node *yaml.Node
+}
+
+// yamlRaw returns the value of the scalar node as extracted value.
+func yamlRaw(node *yaml.Node) interface{} {
+	if node.ShortTag() == "!!int" {
+		in, err := strconv.ParseInt(node.Value, 0, 64)
+		if err == nil {
+			return in
+		}
+	}
+	return node.Value
+}
+
+func (d *yamlDocument) extract(field reflect.StructField) (interface{}, bool) {
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		key, value := d.node.Content[i], d.node.Content[i+1]
+		if !fieldMatchesKey(field, "yaml", key.Value) || value.Kind != yaml.ScalarNode {
+			continue
+		}
+		d.node.Content = append(d.node.Content[:i], d.node.Content[i+2:]...)
+		return yamlRaw(value), true
+	}
+	return nil, false
+}
+
+func (d *yamlDocument) child(field reflect.StructField) (document, bool) {
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		key, value := d.node.Content[i], d.node.Content[i+1]
+		if fieldMatchesKey(field, "yaml", key.Value) && value.Kind == yaml.MappingNode {
+			return &yamlDocument{node: value}, true
+		}
+	}
+	return nil, false
+}
+
+func (d *yamlDocument) position(field reflect.StructField) (int, bool) {
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		if key := d.node.Content[i]; fieldMatchesKey(field, "yaml", key.Value) {
+			return key.Line, true
+		}
+	}
+	return 0, false
+}
+
+func (d *yamlDocument) elements(field reflect.StructField) (*elementsDocument, bool) {
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		key, value := d.node.Content[i], d.node.Content[i+1]
+		if !fieldMatchesKey(field, "yaml", key.Value) {
+			continue
+		}
+		elems := newElementsDocument()
+		switch value.Kind {
+		case yaml.SequenceNode:
+			for j, item := range value.Content {
+				value.Content[j] = addYAMLElement(elems, field, strconv.Itoa(j), item)
+			}
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				value.Content[j+1] = addYAMLElement(elems, field, value.Content[j].Value, value.Content[j+1])
+			}
+		default:
+			return nil, false
+		}
+		return elems, true
+	}
+	return nil, false
+}
+
+// addYAMLElement adds the element item with the key to elems and returns the node that replaces item.
+// Scalars of time types are replaced by null, so that the decoder keeps the indexes of sequences.
+func addYAMLElement(elems *elementsDocument, field reflect.StructField, key string, item *yaml.Node) *yaml.Node {
+	switch {
+	case item.Kind == yaml.MappingNode:
+		elems.add(key, item.Line, nil, &yamlDocument{node: item})
+	case item.Kind == yaml.ScalarNode && isTimeElementType(field):
+		elems.add(key, item.Line, yamlRaw(item), nil)
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null", Line: item.Line, Column: item.Column}
+	}
+	return item
+}
+
+func (d *yamlDocument) decode(receiver interface{}) error {
+	return d.node.Decode(receiver)
+}
+
+type jsonDocument struct {
+	m map[string]interface{}
+}
+
+// jsonRaw returns the scalar value as extracted value. It reports false if value is not a string or number.
+func jsonRaw(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		in, err := v.Int64()
+		if err != nil {
+			return v.String(), true
+		}
+		return in, true
+	}
+	return nil, false
+}
+
+func (d *jsonDocument) extract(field reflect.StructField) (interface{}, bool) {
+	for key, value := range d.m {
+		if !fieldMatchesKey(field, "json", key) {
+			continue
+		}
+		raw, ok := jsonRaw(value)
+		if !ok {
+			continue
+		}
+		delete(d.m, key)
+		return raw, true
+	}
+	return nil, false
+}
+
+func (d *jsonDocument) child(field reflect.StructField) (document, bool) {
+	for key, value := range d.m {
+		if m, ok := value.(map[string]interface{}); ok && fieldMatchesKey(field, "json", key) {
+			return &jsonDocument{m: m}, true
+		}
+	}
+	return nil, false
+}
+
+func (d *jsonDocument) position(field reflect.StructField) (int, bool) {
+	for key := range d.m {
+		if fieldMatchesKey(field, "json", key) {
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+func (d *jsonDocument) elements(field reflect.StructField) (*elementsDocument, bool) {
+	for key, value := range d.m {
+		if !fieldMatchesKey(field, "json", key) {
+			continue
+		}
+		elems := newElementsDocument()
+		switch v := value.(type) {
+		case []interface{}:
+			for i, item := range v {
+				if addJSONElement(elems, field, strconv.Itoa(i), item) {
+					// null keeps the index of the following elements
+					v[i] = nil
+				}
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if addJSONElement(elems, field, k, v[k]) {
+					v[k] = nil
+				}
+			}
+		default:
+			continue
+		}
+		return elems, true
+	}
+	return nil, false
+}
+
+// addJSONElement adds the element item with the key to elems and reports whether item has been extracted.
+func addJSONElement(elems *elementsDocument, field reflect.StructField, key string, item interface{}) bool {
+	if m, ok := item.(map[string]interface{}); ok {
+		elems.add(key, 0, nil, &jsonDocument{m: m})
+		return false
+	}
+	raw, ok := jsonRaw(item)
+	if !ok || !isTimeElementType(field) {
+		return false
+	}
+	elems.add(key, 0, raw, nil)
+	return true
+}
+
+func (d *jsonDocument) decode(receiver interface{}) error {
+	bts, err := json.Marshal(d.m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bts, receiver)
+}
+
+type tomlDocument struct {
+	tree *toml.Tree
+}
+
+func (d *tomlDocument) extract(field reflect.StructField) (interface{}, bool) {
+	for _, key := range d.tree.Keys() {
+		if !fieldMatchesKey(field, "toml", key) {
+			continue
+		}
+		raw := d.tree.Get(key)
+		if !isTOMLRaw(raw) {
+			continue
+		}
+		if d.tree.Delete(key) != nil {
+			continue
+		}
+		return raw, true
+	}
+	return nil, false
+}
+
+func (d *tomlDocument) child(field reflect.StructField) (document, bool) {
+	for _, key := range d.tree.Keys() {
+		if tree, ok := d.tree.Get(key).(*toml.Tree); ok && fieldMatchesKey(field, "toml", key) {
+			return &tomlDocument{tree: tree}, true
+		}
+	}
+	return nil, false
+}
+
+func (d *tomlDocument) position(field reflect.StructField) (int, bool) {
+	for _, key := range d.tree.Keys() {
+		if fieldMatchesKey(field, "toml", key) {
+			return d.tree.GetPosition(key).Line, true
+		}
+	}
+	return 0, false
+}
+
+// isTOMLRaw reports whether the value of a tree can be extracted.
+func isTOMLRaw(value interface{}) bool {
+	switch value.(type) {
+	case string, int64, time.Time, toml.LocalDate, toml.LocalDateTime:
+		return true
+	}
+	return false
+}
+
+func (d *tomlDocument) elements(field reflect.StructField) (*elementsDocument, bool) {
+	for _, key := range d.tree.Keys() {
+		if !fieldMatchesKey(field, "toml", key) {
+			continue
+		}
+		elems := newElementsDocument()
+		switch v := d.tree.Get(key).(type) {
+		case []*toml.Tree:
+			for i, tree := range v {
+				elems.add(strconv.Itoa(i), tree.Position().Line, nil, &tomlDocument{tree: tree})
+			}
+		case []interface{}:
+			if !isTimeElementType(field) {
+				return nil, false
+			}
+			line := d.tree.GetPosition(key).Line
+			for i, item := range v {
+				if isTOMLRaw(item) {
+					elems.add(strconv.Itoa(i), line, item, nil)
+				}
+			}
+			// TOML has no null, so the whole array is removed and the slice is created by setExtractedValue
+			if d.tree.Delete(key) != nil {
+				return nil, false
+			}
+		case *toml.Tree:
+			for _, k := range v.Keys() {
+				item := v.Get(k)
+				line := v.GetPosition(k).Line
+				switch {
+				case isTOMLRaw(item) && isTimeElementType(field):
+					elems.add(k, line, item, nil)
+					if v.Delete(k) != nil {
+						return nil, false
+					}
+				default:
+					if tree, ok := item.(*toml.Tree); ok {
+						elems.add(k, line, nil, &tomlDocument{tree: tree})
+					}
+				}
+			}
+		default:
+			continue
+		}
+		return elems, true
+	}
+	return nil, false
+}
+
+func (d *tomlDocument) decode(receiver interface{}) error {
+	return d.tree.Unmarshal(receiver)
+}
+
+type hclDocument struct {
+	// ast is the root of the document and is only set for the root document.
+	ast *hcl.AST
+	// block is the block of a nested document, its labels belong to the fields tagged with label.
+	block   *hcl.Block
+	entries *[]*hcl.Entry
+}
+
+func (d *hclDocument) extract(field reflect.StructField) (interface{}, bool) {
+	for i, entry := range *d.entries {
+		if entry.Attribute == nil || entry.Attribute.Value == nil || !fieldMatchesKey(field, "hcl", entry.Attribute.Key) {
+			continue
+		}
+		value := entry.Attribute.Value
+		raw, ok := hclRaw(value)
+		if !ok {
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch ft {
+		case durationType, timeType:
+			// attributes are required by default, so the value is replaced by a placeholder
+			// that is overridden after decoding
+			placeholder := "0s"
+			if ft == timeType {
+				placeholder = time.Time{}.Format(time.RFC3339)
+			}
+			entry.Attribute.Value = &hcl.Value{Pos: value.Pos, Parent: value.Parent, Str: &placeholder}
+		default:
+			// the decoder does not support locations, so the field must be optional
+			*d.entries = append((*d.entries)[:i], (*d.entries)[i+1:]...)
+		}
+		return raw, true
+	}
+	return nil, false
+}
+
+func (d *hclDocument) child(field reflect.StructField) (document, bool) {
+	for _, entry := range *d.entries {
+		if entry.Block != nil && fieldMatchesKey(field, "hcl", entry.Block.Name) {
+			return &hclDocument{block: entry.Block, entries: &entry.Block.Body}, true
+		}
+	}
+	return nil, false
+}
+
+func (d *hclDocument) position(field reflect.StructField) (int, bool) {
+	if d.block != nil && len(d.block.Labels) > 0 && hasTagOption(field, "hcl", "label") {
+		return d.block.Pos.Line, true
+	}
+	for _, entry := range *d.entries {
+		switch {
+		case entry.Attribute != nil && fieldMatchesKey(field, "hcl", entry.Attribute.Key):
+			return entry.Attribute.Pos.Line, true
+		case entry.Block != nil && fieldMatchesKey(field, "hcl", entry.Block.Name):
+			return entry.Block.Pos.Line, true
+		}
+	}
+	return 0, false
+}
+
+// hclRaw returns the value as extracted value. It reports false if value is not a string or number.
+func hclRaw(value *hcl.Value) (interface{}, bool) {
+	switch {
+	case value.Str != nil:
+		return *value.Str, true
+	case value.Number != nil && value.Number.IsInt():
+		in, _ := value.Number.Int64()
+		return in, true
+	case value.Number != nil:
+		return value.Number.String(), true
+	}
+	return nil, false
+}
+
+func (d *hclDocument) elements(field reflect.StructField) (*elementsDocument, bool) {
+	elems := newElementsDocument()
+	found := false
+	for i, entry := range *d.entries {
+		switch {
+		case entry.Block != nil && fieldMatchesKey(field, "hcl", entry.Block.Name):
+			// repeated blocks are the elements of a slice
+			found = true
+			elems.add(strconv.Itoa(len(elems.keys)), entry.Block.Pos.Line, nil,
+				&hclDocument{block: entry.Block, entries: &entry.Block.Body})
+		case entry.Attribute != nil && entry.Attribute.Value != nil && fieldMatchesKey(field, "hcl", entry.Attribute.Key):
+			if !isTimeElementType(field) {
+				return nil, false
+			}
+			value := entry.Attribute.Value
+			switch {
+			case value.HaveList:
+				for i, item := range value.List {
+					if raw, ok := hclRaw(item); ok {
+						elems.add(strconv.Itoa(i), item.Pos.Line, raw, nil)
+					}
+				}
+			case value.HaveMap:
+				for _, item := range value.Map {
+					key := item.Key.Type
+					if item.Key.Str != nil {
+						key = item.Key.Str
+					}
+					if raw, ok := hclRaw(item.Value); ok && key != nil {
+						elems.add(*key, item.Pos.Line, raw, nil)
+					}
+				}
+			default:
+				return nil, false
+			}
+			// the decoder does not support time types as elements, so they are set by setExtractedValue
+			if value.HaveList && !isDurationElementType(field) {
+				// lists of structs are decoded as blocks, so the field must be optional
+				*d.entries = append((*d.entries)[:i], (*d.entries)[i+1:]...)
+				return elems, true
+			}
+			entry.Attribute.Value = &hcl.Value{Pos: value.Pos, Parent: value.Parent, HaveList: value.HaveList, HaveMap: value.HaveMap}
+			return elems, true
+		}
+	}
+	return elems, found
+}
+
+func (d *hclDocument) decode(receiver interface{}) error {
+	return hcl.UnmarshalAST(d.ast, receiver)
+}
@@ -0,0 +1,299 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type timeExample struct {
+	Name     string            `hcl:"name"`
+	Timeout  time.Duration     `hcl:"timeout"`
+	Started  time.Time         `hcl:"started"`
+	Birthday time.Time         `hcl:"birthday"`
+	Location *time.Location    `hcl:"location,optional"`
+	Server   timeServerExample `hcl:"server,block"`
+}
+
+type timeServerExample struct {
+	ReadTimeout time.Duration  `hcl:"read_timeout"`
+	Idle        *time.Duration `hcl:"idle"`
+}
+
+// locationComparer compares locations by name, since time.Location has unexported fields.
+var locationComparer = cmp.Comparer(func(a, b *time.Location) bool {
+	return a.String() == b.String()
+})
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestAutoloadAndEnrichConfigWithTimeTypes(t *testing.T) {
+	idle := 5 * time.Second
+	want := &timeExample{
+		Name:     "Simple Sam",
+		Timeout:  30 * time.Second,
+		Started:  time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC),
+		Birthday: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+		Location: mustLoadLocation(t, "Europe/Berlin"),
+		Server: timeServerExample{
+			ReadTimeout: 90 * time.Second,
+			Idle:        &idle,
+		},
+	}
+	tests := []struct {
+		name     string
+		filePath string
+	}{
+		{
+			name:     "yaml",
+			filePath: ".file/time.yml",
+		},
+		{
+			name:     "json",
+			filePath: ".file/time.json",
+		},
+		{
+			name:     "toml",
+			filePath: ".file/time.toml",
+		},
+		{
+			name:     "hcl",
+			filePath: ".file/time.hcl",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &timeExample{}
+			if err := AutoloadAndEnrichConfig(tt.filePath, got); err != nil {
+				t.Fatalf("AutoloadAndEnrichConfig() error = %v", err)
+			}
+			diff := cmp.Diff(got, want, locationComparer)
+			if diff != "" {
+				t.Errorf("AutoloadAndEnrichConfig() diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestAutoloadAndEnrichConfigWithTimeTypesFromEnv(t *testing.T) {
+	os.Setenv("CFG_TIMEOUT", "1h")
+	os.Setenv("CFG_STARTED", "2021-12-24 18:00:00")
+	os.Setenv("CFG_LOCATION", "UTC")
+	os.Setenv("CFG_SERVER_IDLE", "10s")
+	defer func() {
+		os.Unsetenv("CFG_TIMEOUT")
+		os.Unsetenv("CFG_STARTED")
+		os.Unsetenv("CFG_LOCATION")
+		os.Unsetenv("CFG_SERVER_IDLE")
+	}()
+
+	idle := 10 * time.Second
+	want := &timeExample{
+		Name:     "Simple Sam",
+		Timeout:  time.Hour,
+		Started:  time.Date(2021, 12, 24, 18, 0, 0, 0, time.UTC),
+		Birthday: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+		Location: time.UTC,
+		Server: timeServerExample{
+			ReadTimeout: 90 * time.Second,
+			Idle:        &idle,
+		},
+	}
+	got := &timeExample{}
+	if err := AutoloadAndEnrichConfig(".file/time.yml", got); err != nil {
+		t.Fatalf("AutoloadAndEnrichConfig() error = %v", err)
+	}
+	diff := cmp.Diff(got, want, locationComparer)
+	if diff != "" {
+		t.Errorf("AutoloadAndEnrichConfig() diff = %v", diff)
+	}
+}
+
+func Test_setTimeFromString(t *testing.T) {
+	type args struct {
+		v   interface{}
+		raw string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "duration",
+			args: args{
+				v:   new(time.Duration),
+				raw: "1h30m",
+			},
+			want:    90 * time.Minute,
+			wantErr: false,
+		},
+		{
+			name: "invalid duration",
+			args: args{
+				v:   new(time.Duration),
+				raw: "30",
+			},
+			want:    time.Duration(0),
+			wantErr: true,
+		},
+		{
+			name: "rfc3339",
+			args: args{
+				v:   new(time.Time),
+				raw: "2022-01-02T15:04:05.123+01:00",
+			},
+			want:    time.Date(2022, 1, 2, 14, 4, 5, 123000000, time.UTC),
+			wantErr: false,
+		},
+		{
+			name: "date",
+			args: args{
+				v:   new(time.Time),
+				raw: "2022-01-02",
+			},
+			want:    time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+			wantErr: false,
+		},
+		{
+			name: "invalid time",
+			args: args{
+				v:   new(time.Time),
+				raw: "yesterday",
+			},
+			want:    time.Time{},
+			wantErr: true,
+		},
+		{
+			name: "invalid location",
+			args: args{
+				v:   new(time.Location),
+				raw: "Mars/Olympus_Mons",
+			},
+			want:    time.Location{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := reflect.ValueOf(tt.args.v).Elem()
+			if err := defaultLoader().setTimeFromString(v, tt.args.raw); (err != nil) != tt.wantErr {
+				t.Errorf("setTimeFromString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if v.Type() == locationType {
+				return
+			}
+			diff := cmp.Diff(v.Interface(), tt.want)
+			if diff != "" {
+				t.Errorf("setTimeFromString() diff = %v", diff)
+			}
+		})
+	}
+}
+
+func Test_loadAndParseFileWithInvalidTime(t *testing.T) {
+	type example struct {
+		Name    string
+		Timeout time.Duration
+	}
+	dir := t.TempDir()
+	filePath := dir + "/config.json"
+	err := ioutil.WriteFile(filePath, []byte(`{"name": "a", "timeout": "soon"}`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write %s: %v", filePath, err)
+	}
+	if err := defaultLoader().loadAndParseFile(filePath, &example{}, JSON); err == nil {
+		t.Errorf("loadAndParseFile() error = %v, wantErr true", err)
+	}
+}
+
+func TestLoader_LoadBytesWithNestedTimeTypes(t *testing.T) {
+	type Base struct {
+		Wait time.Duration `hcl:"wait,optional"`
+	}
+	type item struct {
+		Timeout time.Duration `hcl:"timeout,optional"`
+		Name    string        `hcl:"name,optional"`
+	}
+	type example struct {
+		Base      `yaml:",inline"`
+		Timeouts  []time.Duration           `hcl:"timeouts,optional"`
+		Deadlines map[string]*time.Duration `hcl:"deadlines,optional"`
+		Items     []item                    `hcl:"items,block"`
+		Dates     []time.Time               `hcl:"dates,optional"`
+	}
+	deadline := 3 * time.Second
+	want := &example{
+		Base:      Base{Wait: 2 * time.Second},
+		Timeouts:  []time.Duration{time.Second, 5 * time.Minute},
+		Deadlines: map[string]*time.Duration{"read": &deadline},
+		Items:     []item{{Timeout: 4 * time.Second, Name: "a"}, {Name: "b"}},
+		Dates:     []time.Time{time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	tests := []struct {
+		name    string
+		data    string
+		f       Format
+		want    *example
+		wantErr bool
+	}{
+		{
+			name: "yaml",
+			data: "wait: 2s\ntimeouts: [1s, 5m]\ndeadlines: {read: 3s}\nitems: [{timeout: 4s, name: a}, {name: b}]\ndates: [2022-01-02]\n",
+			f:    YAML,
+			want: want,
+		},
+		{
+			name: "json",
+			data: `{"wait": "2s", "timeouts": ["1s", "5m"], "deadlines": {"read": "3s"}, "items": [{"timeout": "4s", "name": "a"}, {"name": "b"}], "dates": ["2022-01-02"]}`,
+			f:    JSON,
+			want: want,
+		},
+		{
+			name: "toml",
+			data: "wait = \"2s\"\ntimeouts = [\"1s\", \"5m\"]\ndates = [\"2022-01-02\"]\n[deadlines]\nread = \"3s\"\n[[items]]\ntimeout = \"4s\"\nname = \"a\"\n[[items]]\nname = \"b\"\n",
+			f:    TOML,
+			want: want,
+		},
+		{
+			name: "hcl",
+			data: "wait = \"2s\"\ntimeouts = [\"1s\", \"5m\"]\ndates = [\"2022-01-02\"]\ndeadlines = {read: \"3s\"}\nitems {\n  timeout = \"4s\"\n  name = \"a\"\n}\nitems {\n  name = \"b\"\n}\n",
+			f:    HCL,
+			want: want,
+		},
+		{
+			name:    "invalid element",
+			data:    `{"timeouts": ["1s", "soon"]}`,
+			f:       JSON,
+			want:    &example{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &example{}
+			err := NewLoader(WithEnviron(func() []string { return nil })).LoadBytes([]byte(tt.data), tt.f, got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Loader.LoadBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Loader.LoadBytes() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
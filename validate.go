@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// validateTag is the struct tag used to define the validation rules of a field.
+// Multiple rules are separated by a comma, parameters are separated by an equal sign.
+//
+// Supported rules:
+// - required: the field must not hold the zero value.
+// - min=<n>: numbers must be greater than or equal to n, strings, slices and maps must have a length of at least n.
+// - max=<n>: numbers must be less than or equal to n, strings, slices and maps must have a length of at most n.
+// - oneof=<a b c>: the value must be one of the space separated values.
+//
+// Rules of pointer fields are checked against the value they point to, nil pointers only fail the required rule.
+const validateTag = "validate"
+
+// Validator can be implemented by a config struct or any nested struct to perform custom validations.
+// Validate is called after the rules of the validate tags have been checked.
+type Validator interface {
+	Validate() error
+}
+
+// Validate checks the validate tags of all fields of the struct st and calls Validate on every struct that implements Validator.
+// If one or more fields are invalid, ValidationErrors is returned.
+// @st: The pointer to the struct to validate.
+func Validate(st interface{}) error {
+	val := reflect.ValueOf(st)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("receiver must be a struct or a pointer to a struct, got: %T", st)
+	}
+	errs := ValidationErrors{}
+	err := validateStruct(val, "", &errs)
+	if err != nil {
+		return err
+	}
+	if v, ok := st.(Validator); ok {
+		err := v.Validate()
+		if err != nil {
+			errs = append(errs, &FieldError{Rule: "validator", Message: err.Error()})
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateStruct is the recursive part of Validate.
+// An error is only returned if a validate tag is malformed. Invalid fields are collected in errs.
+// @val: The struct value to validate.
+// @fieldPath: The path of val within the receiver.
+// @errs: The collected validation errors.
+func validateStruct(val reflect.Value, fieldPath string, errs *ValidationErrors) error {
+	for i := 0; i < val.NumField(); i++ {
+		f := val.Field(i)
+		if !f.CanSet() {
+			// unexported field
+			continue
+		}
+		field := val.Type().Field(i)
+		path := joinFieldPath(fieldPath, field.Name)
+		if tag, ok := field.Tag.Lookup(validateTag); ok {
+			for _, rule := range strings.Split(tag, ",") {
+				name, param := rule, ""
+				if idx := strings.Index(rule, "="); idx >= 0 {
+					name, param = rule[:idx], rule[idx+1:]
+				}
+				msg, err := checkRule(f, strings.TrimSpace(name), param)
+				if err != nil {
+					return fmt.Errorf("invalid validate tag %q for field %s: %w", tag, path, err)
+				}
+				if msg != "" {
+					*errs = append(*errs, &FieldError{Field: path, Rule: name, Message: msg})
+				}
+			}
+		}
+
+		err := validateNested(f, path, errs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNested validates the structs that v contains, i.e. v itself if it is a struct and the elements of
+// slices, arrays and maps. Elements are identified by their index or key, e.g. Upstreams[0].Host or Labels[team].
+// Nil pointers are skipped.
+// @v: The value to validate.
+// @fieldPath: The path of v within the receiver.
+// @errs: The collected validation errors.
+func validateNested(v reflect.Value, fieldPath string, errs *ValidationErrors) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if isScalarType(v.Type()) {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if !v.CanAddr() {
+			// map elements are not addressable, but Validator may be implemented by the pointer
+			addressable := reflect.New(v.Type()).Elem()
+			addressable.Set(v)
+			v = addressable
+		}
+		err := validateStruct(v, fieldPath, errs)
+		if err != nil {
+			return err
+		}
+		if validator, ok := v.Addr().Interface().(Validator); ok {
+			err := validator.Validate()
+			if err != nil {
+				*errs = append(*errs, &FieldError{Field: fieldPath, Rule: "validator", Message: err.Error()})
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			err := validateNested(v.Index(i), fmt.Sprintf("%s[%d]", fieldPath, i), errs)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		// the keys are sorted, so the errors have a stable order
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			err := validateNested(v.MapIndex(key), fmt.Sprintf("%s[%v]", fieldPath, key.Interface()), errs)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkRule checks a single rule against v.
+// It returns a message describing the violation or an empty string if v is valid.
+// Pointers are dereferenced, nil pointers only fail the required rule.
+// @v: The value to check.
+// @name: The name of the rule.
+// @param: The parameter of the rule.
+func checkRule(v reflect.Value, name, param string) (string, error) {
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return "is required", nil
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", fmt.Errorf("rule %s: %w", name, err)
+		}
+		v, ok := indirect(v)
+		if !ok {
+			return "", nil
+		}
+		n, isLen, ok := numericValue(v)
+		if !ok {
+			return "", fmt.Errorf("rule %s is not supported for kind %s", name, v.Kind())
+		}
+		what := "value"
+		if isLen {
+			what = "length"
+		}
+		if name == "min" && n < limit {
+			return fmt.Sprintf("%s must be at least %s", what, param), nil
+		}
+		if name == "max" && n > limit {
+			return fmt.Sprintf("%s must be at most %s", what, param), nil
+		}
+	case "oneof":
+		v, ok := indirect(v)
+		if !ok {
+			return "", nil
+		}
+		allowed := strings.Fields(param)
+		actual := fmt.Sprint(v.Interface())
+		for _, a := range allowed {
+			if a == actual {
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", strings.Join(allowed, " ")), nil
+	default:
+		return "", fmt.Errorf("unknown rule %q", name)
+	}
+	return "", nil
+}
+
+// indirect dereferences the pointer v until it is no pointer. ok is false if a nil pointer is found.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+// numericValue returns the value of numbers or the length of strings, slices, arrays and maps as float64.
+// isLen is true if the length is returned.
+func numericValue(v reflect.Value) (n float64, isLen bool, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true, true
+	default:
+		return 0, false, false
+	}
+}
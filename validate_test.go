@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type validateServerExample struct {
+	Host string `validate:"required"`
+	Port int    `validate:"min=1,max=65535"`
+}
+
+type validateExample struct {
+	LogLevel  string   `validate:"oneof=debug info warn"`
+	Hosts     []string `validate:"min=1"`
+	Server    validateServerExample
+	TLS       *validateTLSExample
+	Workers   *int `validate:"min=1,max=8"`
+	Upstreams []validateServerExample
+	Certs     map[string]validateTLSExample
+}
+
+type validateTLSExample struct {
+	CertFile string
+	KeyFile  string
+}
+
+func (t *validateTLSExample) Validate() error {
+	if t.CertFile != "" && t.KeyFile == "" {
+		return errors.New("key file must be set if cert file is set")
+	}
+	return nil
+}
+
+type validateInvalidTagExample struct {
+	Port int `validate:"between=1"`
+}
+
+func TestValidate(t *testing.T) {
+	type args struct {
+		st interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    ValidationErrors
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			args: args{
+				st: &validateExample{
+					LogLevel: "info",
+					Hosts:    []string{"localhost"},
+					Server: validateServerExample{
+						Host: "localhost",
+						Port: 8080,
+					},
+					TLS: &validateTLSExample{
+						CertFile: "cert.pem",
+						KeyFile:  "key.pem",
+					},
+					Upstreams: []validateServerExample{{Host: "a", Port: 80}},
+					Certs:     map[string]validateTLSExample{"a": {CertFile: "a.pem", KeyFile: "a.key"}},
+				},
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "invalid",
+			args: args{
+				st: &validateExample{
+					LogLevel: "trace",
+					Server: validateServerExample{
+						Port: 70000,
+					},
+					TLS: &validateTLSExample{
+						CertFile: "cert.pem",
+					},
+					Workers:   new(int),
+					Upstreams: []validateServerExample{{Host: "a", Port: 80}, {Port: 80}},
+					Certs:     map[string]validateTLSExample{"b": {CertFile: "b.pem"}, "a": {}},
+				},
+			},
+			want: ValidationErrors{
+				{Field: "LogLevel", Rule: "oneof", Message: "must be one of [debug info warn]"},
+				{Field: "Hosts", Rule: "min", Message: "length must be at least 1"},
+				{Field: "Server.Host", Rule: "required", Message: "is required"},
+				{Field: "Server.Port", Rule: "max", Message: "value must be at most 65535"},
+				{Field: "TLS", Rule: "validator", Message: "key file must be set if cert file is set"},
+				{Field: "Workers", Rule: "min", Message: "value must be at least 1"},
+				{Field: "Upstreams[1].Host", Rule: "required", Message: "is required"},
+				{Field: "Certs[b]", Rule: "validator", Message: "key file must be set if cert file is set"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid tag",
+			args: args{
+				st: &validateInvalidTagExample{},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "no struct",
+			args: args{
+				st: "string",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.args.st)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				return
+			}
+			diff := cmp.Diff(err, tt.want)
+			if diff != "" {
+				t.Errorf("Validate() diff = %v", diff)
+			}
+		})
+	}
+}
+
+type validateRootExample struct {
+	Name string
+}
+
+func (v validateRootExample) Validate() error {
+	if v.Name == "" {
+		return errors.New("name must be set")
+	}
+	return nil
+}
+
+func TestValidate_rootValidator(t *testing.T) {
+	err := Validate(&validateRootExample{})
+	want := ValidationErrors{
+		{Rule: "validator", Message: "name must be set"},
+	}
+	diff := cmp.Diff(err, want)
+	if diff != "" {
+		t.Errorf("Validate() diff = %v", diff)
+	}
+	if err.Error() != "validation failed for 1 field(s): name must be set" {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestAutoloadAndEnrichConfigWithValidation(t *testing.T) {
+	type example struct {
+		Name string `validate:"required"`
+		Age  int    `validate:"min=30"`
+	}
+	err := AutoloadAndEnrichConfig(".file/simple.yml", &example{})
+	want := ValidationErrors{
+		{Field: "Age", Rule: "min", Message: "value must be at least 30"},
+	}
+	diff := cmp.Diff(err, want)
+	if diff != "" {
+		t.Errorf("AutoloadAndEnrichConfig() diff = %v", diff)
+	}
+}
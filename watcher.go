@@ -0,0 +1,250 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Change describes a reload of the config file that resulted in a different config.
+type Change struct {
+	// Old is the config before the reload.
+	Old interface{}
+	// New is the config after the reload.
+	New interface{}
+	// Fields contains every field that has been changed.
+	Fields []FieldChange
+}
+
+// FieldChange describes a single field that has been changed by a reload.
+type FieldChange struct {
+	// Field is the path of the field, e.g. Server.Port.
+	Field string
+	// Old is the value before the reload.
+	Old interface{}
+	// New is the value after the reload.
+	New interface{}
+}
+
+// Watcher watches a config file and reloads it on every change.
+// The file is polled in a fixed interval, since this does not require platform specific notification APIs.
+// A reload parses the file into a fresh receiver, enriches it with the env variables and validates it.
+// If any of these steps fails, the current config is kept and the error is passed to the error handlers.
+type Watcher struct {
+	filePath string
+	loader   *Loader
+	interval time.Duration
+	typ      reflect.Type
+
+	mu            sync.RWMutex
+	current       interface{}
+	modTime       time.Time
+	size          int64
+	subscribers   []func(Change)
+	errorHandlers []func(error)
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewWatcher loads the config file into the receiver and returns a Watcher for the file.
+// The receiver is only used for the initial load, reloaded configs are available via Current and Subscribe.
+// @filePath: The path to the config file.
+// @prefix: The prefix to use for the env variables.
+// @receiver: The receiver to parse the config file into. Must be a pointer to a struct.
+// @interval: The interval in which the file is checked for changes.
+func NewWatcher(filePath, prefix string, receiver interface{}, interval time.Duration) (*Watcher, error) {
+	return defaultLoader(WithEnvPrefix(prefix)).Watch(filePath, receiver, interval)
+}
+
+// Watch loads the config file into the receiver and returns a Watcher for the file that reloads it with the Loader.
+// The receiver is only used for the initial load, reloaded configs are available via Current and Subscribe.
+// @filePath: The path to the config file.
+// @receiver: The receiver to parse the config file into. Must be a pointer to a struct.
+// @interval: The interval in which the file is checked for changes.
+func (l *Loader) Watch(filePath string, receiver interface{}, interval time.Duration) (*Watcher, error) {
+	val := reflect.ValueOf(receiver)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return nil, fmt.Errorf("receiver must be a non-nil pointer, got: %T", receiver)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be greater than zero, got: %s", interval)
+	}
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	err = l.Load(filePath, receiver)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		filePath: filePath,
+		loader:   l,
+		interval: interval,
+		typ:      val.Elem().Type(),
+		current:  receiver,
+		modTime:  stat.ModTime(),
+		size:     stat.Size(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Current returns the currently active config.
+// The returned value is a pointer of the same type as the receiver passed to NewWatcher or Watch and must not be modified.
+func (w *Watcher) Current() interface{} {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe registers a callback that is called after every reload that changed the config.
+// Callbacks are called sequentially from the goroutine that performed the reload.
+func (w *Watcher) Subscribe(fn func(Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// OnError registers a callback that is called if the file could not be checked or reloaded.
+func (w *Watcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.errorHandlers = append(w.errorHandlers, fn)
+}
+
+// Start starts polling the config file in the background.
+// Calling Start more than once or after Stop has no effect.
+func (w *Watcher) Start() {
+	w.startOnce.Do(func() {
+		go w.run()
+	})
+}
+
+// Stop stops polling the config file and waits until the background goroutine has returned.
+// It may be called multiple times and without Start, in which case the Watcher can no longer be started.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	w.startOnce.Do(func() {
+		// the Watcher has not been started, so there is no goroutine to wait for
+		close(w.done)
+	})
+	<-w.done
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			changed, err := w.fileChanged()
+			if err != nil {
+				w.notifyError(err)
+				continue
+			}
+			if changed {
+				// errors are passed to the error handlers by Reload
+				_ = w.Reload()
+			}
+		}
+	}
+}
+
+// fileChanged reports whether the modification time or the size of the file has changed since the last check.
+func (w *Watcher) fileChanged() (bool, error) {
+	stat, err := os.Stat(w.filePath)
+	if err != nil {
+		return false, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if stat.ModTime().Equal(w.modTime) && stat.Size() == w.size {
+		return false, nil
+	}
+	w.modTime = stat.ModTime()
+	w.size = stat.Size()
+	return true, nil
+}
+
+// Reload parses the config file into a fresh receiver and replaces the current config if the new config is valid
+// and differs from the current one. In this case the subscribers are notified.
+// If the file can not be loaded, the current config is kept and the error is returned and passed to the error handlers.
+func (w *Watcher) Reload() error {
+	fresh := reflect.New(w.typ).Interface()
+	err := w.loader.Load(w.filePath, fresh)
+	if err != nil {
+		err = fmt.Errorf("failed to reload %q: %w", w.filePath, err)
+		w.notifyError(err)
+		return err
+	}
+
+	w.mu.Lock()
+	old := w.current
+	fields := []FieldChange{}
+	diffValues(reflect.ValueOf(old).Elem(), reflect.ValueOf(fresh).Elem(), "", &fields)
+	if len(fields) == 0 {
+		// keep the current config, so that its pointer stays stable
+		w.mu.Unlock()
+		return nil
+	}
+	w.current = fresh
+	subscribers := append([]func(Change){}, w.subscribers...)
+	w.mu.Unlock()
+
+	change := Change{
+		Old:    old,
+		New:    fresh,
+		Fields: fields,
+	}
+	for _, fn := range subscribers {
+		fn(change)
+	}
+	return nil
+}
+
+func (w *Watcher) notifyError(err error) {
+	w.mu.RLock()
+	handlers := append([]func(error){}, w.errorHandlers...)
+	w.mu.RUnlock()
+	for _, fn := range handlers {
+		fn(err)
+	}
+}
+
+// diffValues compares old and new and collects every differing field.
+// Structs are compared field by field, all other kinds are compared as a whole.
+// @old: The value before the change.
+// @new: The value after the change.
+// @fieldPath: The path of the values within the receiver.
+// @changes: The collected changes.
+func diffValues(old, new reflect.Value, fieldPath string, changes *[]FieldChange) {
+	if old.Kind() == reflect.Struct && !isScalarType(old.Type()) {
+		for i := 0; i < old.NumField(); i++ {
+			if !old.Field(i).CanInterface() {
+				// unexported field
+				continue
+			}
+			diffValues(old.Field(i), new.Field(i), joinFieldPath(fieldPath, old.Type().Field(i).Name), changes)
+		}
+		return
+	}
+	if reflect.DeepEqual(old.Interface(), new.Interface()) {
+		return
+	}
+	*changes = append(*changes, FieldChange{
+		Field: fieldPath,
+		Old:   old.Interface(),
+		New:   new.Interface(),
+	})
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type watcherExample struct {
+	Name   string
+	Server struct {
+		Port int `validate:"max=65535"`
+	}
+}
+
+func writeWatcherFile(t *testing.T, filePath, content string) {
+	t.Helper()
+	err := ioutil.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("failed to write %s: %v", filePath, err)
+	}
+}
+
+func TestNewWatcher(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "config.yml")
+	writeWatcherFile(t, filePath, "name: a\nserver:\n  port: 80\n")
+
+	tests := []struct {
+		name     string
+		filePath string
+		receiver interface{}
+		interval time.Duration
+		wantErr  bool
+	}{
+		{
+			name:     "valid",
+			filePath: filePath,
+			receiver: &watcherExample{},
+			interval: time.Second,
+			wantErr:  false,
+		},
+		{
+			name:     "non pointer receiver",
+			filePath: filePath,
+			receiver: watcherExample{},
+			interval: time.Second,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid interval",
+			filePath: filePath,
+			receiver: &watcherExample{},
+			interval: 0,
+			wantErr:  true,
+		},
+		{
+			name:     "missing file",
+			filePath: filepath.Join(dir, "missing.yml"),
+			receiver: &watcherExample{},
+			interval: time.Second,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWatcher(tt.filePath, "cfg", tt.receiver, tt.interval)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewWatcher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "config.yml")
+	writeWatcherFile(t, filePath, "name: a\nserver:\n  port: 80\n")
+
+	receiver := &watcherExample{}
+	w, err := NewWatcher(filePath, "cfg", receiver, time.Hour)
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	changes := []Change{}
+	w.Subscribe(func(c Change) {
+		changes = append(changes, c)
+	})
+	errs := []error{}
+	w.OnError(func(err error) {
+		errs = append(errs, err)
+	})
+
+	// unchanged file does not notify the subscribers
+	if err := w.Reload(); err != nil {
+		t.Errorf("Reload() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Reload() changes = %v, want none", changes)
+	}
+
+	writeWatcherFile(t, filePath, "name: b\nserver:\n  port: 8080\n")
+	if err := w.Reload(); err != nil {
+		t.Errorf("Reload() error = %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("Reload() changes = %d, want 1", len(changes))
+	}
+	want := []FieldChange{
+		{Field: "Name", Old: "a", New: "b"},
+		{Field: "Server.Port", Old: 80, New: 8080},
+	}
+	diff := cmp.Diff(changes[0].Fields, want)
+	if diff != "" {
+		t.Errorf("Reload() diff = %v", diff)
+	}
+	if changes[0].Old != receiver || changes[0].New != w.Current() {
+		t.Errorf("Reload() change does not reference the old and the new config")
+	}
+
+	// an invalid config keeps the current one
+	writeWatcherFile(t, filePath, "name: c\nserver:\n  port: 70000\n")
+	err = w.Reload()
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Errorf("Reload() error = %v, want ValidationErrors", err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("Reload() error handlers called %d times, want 1", len(errs))
+	}
+	if got := w.Current().(*watcherExample).Name; got != "b" {
+		t.Errorf("Current() name = %v, want b", got)
+	}
+	if len(changes) != 1 {
+		t.Errorf("Reload() changes = %d, want 1", len(changes))
+	}
+}
+
+func TestWatcher_StartStop(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "config.yml")
+	writeWatcherFile(t, filePath, "name: a\n")
+
+	w, err := NewWatcher(filePath, "cfg", &watcherExample{}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	ch := make(chan Change, 1)
+	w.Subscribe(func(c Change) {
+		ch <- c
+	})
+	w.Start()
+	defer w.Stop()
+
+	writeWatcherFile(t, filePath, "name: changed\n")
+	select {
+	case c := <-ch:
+		if got := c.New.(*watcherExample).Name; got != "changed" {
+			t.Errorf("Change.New name = %v, want changed", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not detect the change")
+	}
+}
+
+func TestWatcher_StartStopTwice(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "config.yml")
+	writeWatcherFile(t, filePath, "name: a\n")
+	tests := []struct {
+		name string
+		run  func(w *Watcher)
+	}{
+		{
+			name: "start twice",
+			run: func(w *Watcher) {
+				w.Start()
+				w.Start()
+				w.Stop()
+			},
+		},
+		{
+			name: "stop twice",
+			run: func(w *Watcher) {
+				w.Start()
+				w.Stop()
+				w.Stop()
+			},
+		},
+		{
+			name: "stop without start",
+			run: func(w *Watcher) {
+				w.Stop()
+				w.Start()
+				w.Stop()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewWatcher(filePath, "cfg", &watcherExample{}, 10*time.Millisecond)
+			if err != nil {
+				t.Fatalf("NewWatcher() error = %v", err)
+			}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				tt.run(w)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Watcher.Stop() did not return")
+			}
+		})
+	}
+}